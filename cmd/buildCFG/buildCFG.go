@@ -5,14 +5,22 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/wb-go/wbf/config"
 	"github.com/wb-go/wbf/dbpg"
+	"net"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// minAdminTokenLen — минимальная длина server.token
+const minAdminTokenLen = 16
+
 type ServerConfig struct {
 	Port         string
 	Name         string
 	WriteTimeout time.Duration
+	Token        string
+	// адреса прокси, которым можно верить в X-Forwarded-For; пусто — IP клиента берётся из соединения
+	TrustedProxies []string
 }
 type RedisConfig struct {
 	Addr     string
@@ -30,12 +38,37 @@ func BuildServerConfig(cfg *config.Config, log *zerolog.Logger) ServerConfig {
 		log.Fatal().Msgf("invalid write_timeout value: %v", err)
 	}
 
-	log.Info().Msgf("Starting %s on port %s (timeout %s)", serverName, port, writeTimeout)
+	// токен выпускает API-ключи любого workspace, поэтому короткий угадываемый токен не принимается
+	token := cfg.GetString("server.token")
+	if token == "" {
+		log.Warn().Msg("server.token is empty, admin endpoints are disabled")
+	} else if len(token) < minAdminTokenLen {
+		log.Fatal().Msgf("server.token is too short: at least %d characters required, e.g. openssl rand -hex 32", minAdminTokenLen)
+	}
+
+	// IP или подсети через запятую: "10.0.0.0/8,127.0.0.1"
+	var trustedProxies []string
+	for _, proxy := range strings.Split(cfg.GetString("server.trusted_proxies"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy == "" {
+			continue
+		}
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				log.Fatal().Msgf("invalid server.trusted_proxies entry: %s", proxy)
+			}
+		}
+		trustedProxies = append(trustedProxies, proxy)
+	}
+
+	log.Info().Msgf("Starting %s on port %s (timeout %s, trusted proxies: %d)", serverName, port, writeTimeout, len(trustedProxies))
 
 	return ServerConfig{
 		Port:         port,
 		Name:         serverName,
 		WriteTimeout: writeTimeout,
+		Token:        token,
+
+		TrustedProxies: trustedProxies,
 	}
 }
 func BuildDBConfig(cfg *config.Config, log *zerolog.Logger) (string, []string, *dbpg.Options, error) {
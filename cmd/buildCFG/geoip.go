@@ -0,0 +1,32 @@
+package buildCFG
+
+import (
+	"github.com/rs/zerolog"
+	"github.com/wb-go/wbf/config"
+	"time"
+)
+
+type GeoIPConfig struct {
+	Path           string // пустой путь отключает GeoIP
+	ReloadInterval time.Duration
+}
+
+func BuildGeoIPConfig(cfg *config.Config, log *zerolog.Logger) (*GeoIPConfig, error) {
+	reloadInterval, err := durationOrDefault(cfg, "geoip.reload_interval", time.Minute)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+
+	path := cfg.GetString("geoip.path")
+	if path == "" {
+		log.Info().Msg("GeoIP is disabled: geoip.path is empty")
+	} else {
+		log.Info().Msgf("GeoIP config: path=%s reload_interval=%s", path, reloadInterval)
+	}
+
+	return &GeoIPConfig{
+		Path:           path,
+		ReloadInterval: reloadInterval,
+	}, nil
+}
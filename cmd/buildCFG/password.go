@@ -0,0 +1,57 @@
+package buildCFG
+
+import (
+	"crypto/rand"
+	"fmt"
+	"github.com/rs/zerolog"
+	"github.com/wb-go/wbf/config"
+	"time"
+)
+
+type PasswordConfig struct {
+	Secret        []byte // ключ подписи cookie доступа к защищённым ссылкам
+	CookieTTL     time.Duration
+	MaxAttempts   int // неудачных попыток ввода пароля с одного IP за AttemptWindow
+	AttemptWindow time.Duration
+}
+
+func BuildPasswordConfig(cfg *config.Config, log *zerolog.Logger) (*PasswordConfig, error) {
+	cookieTTL, err := durationOrDefault(cfg, "password.cookie_ttl", time.Hour)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+	maxAttempts, err := intOrDefault(cfg, "password.max_attempts", 5)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+	if maxAttempts < 1 {
+		log.Error().Msg("password.max_attempts must be positive")
+		return nil, fmt.Errorf("password.max_attempts must be positive")
+	}
+	attemptWindow, err := durationOrDefault(cfg, "password.attempt_window", 15*time.Minute)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+
+	secret := []byte(cfg.GetString("password.secret"))
+	if len(secret) == 0 {
+		// без общего ключа cookie не переживут рестарт и не подойдут другим репликам
+		log.Warn().Msg("password.secret is empty, using a random key for this process")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to generate password cookie secret: %w", err)
+		}
+	}
+
+	log.Info().Msgf("Password config: cookie_ttl=%s max_attempts=%d attempt_window=%s", cookieTTL, maxAttempts, attemptWindow)
+
+	return &PasswordConfig{
+		Secret:        secret,
+		CookieTTL:     cookieTTL,
+		MaxAttempts:   maxAttempts,
+		AttemptWindow: attemptWindow,
+	}, nil
+}
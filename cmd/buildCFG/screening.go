@@ -0,0 +1,83 @@
+package buildCFG
+
+import (
+	"fmt"
+	"github.com/rs/zerolog"
+	"github.com/wb-go/wbf/config"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type ScreeningConfig struct {
+	Schemes        []string
+	AllowPrivate   bool
+	ResolveHosts   bool
+	BlocklistPath  string // пустой путь — без списка запрещённых доменов
+	ReloadInterval time.Duration
+	HookURL        string // пусто — внешняя проверка отключена
+	HookTimeout    time.Duration
+}
+
+func BuildScreeningConfig(cfg *config.Config, log *zerolog.Logger) (*ScreeningConfig, error) {
+	allowPrivate, err := boolOrDefault(cfg, "screening.allow_private", false)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+	resolveHosts, err := boolOrDefault(cfg, "screening.resolve_hosts", false)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+	reloadInterval, err := durationOrDefault(cfg, "screening.reload_interval", time.Minute)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+	hookTimeout, err := durationOrDefault(cfg, "screening.hook_timeout", 2*time.Second)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+
+	// разрешённые схемы через запятую: "http,https"
+	var schemes []string
+	for _, scheme := range strings.Split(cfg.GetString("screening.schemes"), ",") {
+		if scheme = strings.ToLower(strings.TrimSpace(scheme)); scheme != "" {
+			schemes = append(schemes, scheme)
+		}
+	}
+	if len(schemes) == 0 {
+		schemes = []string{"http", "https"}
+	}
+	for _, scheme := range schemes {
+		switch scheme {
+		case "javascript", "data", "vbscript", "file":
+			log.Error().Msgf("Unsafe scheme in screening.schemes: %s", scheme)
+			return nil, fmt.Errorf("screening.schemes must not contain %s", scheme)
+		}
+	}
+
+	hookURL := cfg.GetString("screening.hook_url")
+	if hookURL != "" {
+		if u, err := url.Parse(hookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			log.Error().Msgf("Invalid screening.hook_url: %s", hookURL)
+			return nil, fmt.Errorf("screening.hook_url must be an http(s) URL")
+		}
+	}
+
+	blocklistPath := cfg.GetString("screening.blocklist_path")
+	log.Info().Msgf("Screening config: schemes=%s allow_private=%t resolve_hosts=%t blocklist_path=%q hook_url=%q",
+		strings.Join(schemes, ","), allowPrivate, resolveHosts, blocklistPath, hookURL)
+
+	return &ScreeningConfig{
+		Schemes:        schemes,
+		AllowPrivate:   allowPrivate,
+		ResolveHosts:   resolveHosts,
+		BlocklistPath:  blocklistPath,
+		ReloadInterval: reloadInterval,
+		HookURL:        hookURL,
+		HookTimeout:    hookTimeout,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package buildCFG
+
+import (
+	"fmt"
+	"github.com/rs/zerolog"
+	"github.com/wb-go/wbf/config"
+	"strconv"
+)
+
+type SeedConfig struct {
+	Enabled       bool
+	FixturesDir   string
+	Links         int
+	ClicksPerLink int
+	Days          int
+}
+
+func BuildSeedConfig(cfg *config.Config, log *zerolog.Logger) (*SeedConfig, error) {
+	enabled, err := boolOrDefault(cfg, "seed.enabled", false)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+
+	fixturesDir := cfg.GetString("seed.fixtures_dir")
+	if fixturesDir == "" {
+		fixturesDir = "seeds/postgres"
+	}
+
+	links, err := intOrDefault(cfg, "seed.links", 100)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+	clicks, err := intOrDefault(cfg, "seed.clicks_per_link", 50)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+	days, err := intOrDefault(cfg, "seed.days", 90)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+
+	return &SeedConfig{
+		Enabled:       enabled,
+		FixturesDir:   fixturesDir,
+		Links:         links,
+		ClicksPerLink: clicks,
+		Days:          days,
+	}, nil
+}
+
+// intOrDefault читает целое значение, пустое значение заменяется на def
+func intOrDefault(cfg *config.Config, key string, def int) (int, error) {
+	v := cfg.GetString(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
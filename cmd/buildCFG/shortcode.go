@@ -0,0 +1,57 @@
+package buildCFG
+
+import (
+	"fmt"
+	"github.com/rs/zerolog"
+	"github.com/wb-go/wbf/config"
+	"secondOne/pkg/shortcode"
+	"strconv"
+)
+
+func BuildShortCodeConfig(cfg *config.Config, log *zerolog.Logger) (shortcode.Config, error) {
+	strategy := cfg.GetString("shortcode.strategy")
+	if strategy == "" {
+		strategy = shortcode.StrategyRandom
+	}
+
+	alphabet := cfg.GetString("shortcode.alphabet")
+	if alphabet == "" {
+		alphabet = shortcode.DefaultAlphabet
+	}
+
+	length, err := intOrDefault(cfg, "shortcode.length", 6)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return shortcode.Config{}, err
+	}
+	maxAttempts, err := intOrDefault(cfg, "shortcode.max_attempts", 10)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return shortcode.Config{}, err
+	}
+	growAfter, err := intOrDefault(cfg, "shortcode.grow_after", 3)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return shortcode.Config{}, err
+	}
+
+	var salt uint64
+	if v := cfg.GetString("shortcode.salt"); v != "" {
+		salt, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			log.Error().Msgf("invalid shortcode.salt: %v", err)
+			return shortcode.Config{}, fmt.Errorf("invalid shortcode.salt: %w", err)
+		}
+	}
+
+	log.Info().Msgf("Short code config: strategy=%s length=%d alphabet=%d chars", strategy, length, len(alphabet))
+
+	return shortcode.Config{
+		Strategy:    strategy,
+		Length:      length,
+		Alphabet:    alphabet,
+		MaxAttempts: maxAttempts,
+		GrowAfter:   growAfter,
+		Salt:        salt,
+	}, nil
+}
@@ -0,0 +1,141 @@
+package buildCFG
+
+import (
+	"fmt"
+	"github.com/rs/zerolog"
+	"github.com/wb-go/wbf/config"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ShortenerConfig struct {
+	Dedup          bool
+	IdempotencyTTL time.Duration
+	BatchMaxItems  int
+	RedirectCode   int
+	PrelaunchMode  string
+	ComingSoonURL  string
+	ExpiredURL     string
+	NotFoundURL    string
+	BaseURL        string
+	QRCacheTTL     time.Duration
+	ReservedWords  []string
+}
+
+func BuildShortenerConfig(cfg *config.Config, log *zerolog.Logger) (*ShortenerConfig, error) {
+	dedup, err := boolOrDefault(cfg, "shortener.dedup", false)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+
+	idempotencyTTL, err := durationOrDefault(cfg, "shortener.idempotency_ttl", 24*time.Hour)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+
+	batchMaxItems, err := intOrDefault(cfg, "shortener.batch_max_items", 1000)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+	if batchMaxItems < 1 {
+		log.Error().Msg("shortener.batch_max_items must be positive")
+		return nil, fmt.Errorf("shortener.batch_max_items must be positive")
+	}
+
+	redirectCode, err := intOrDefault(cfg, "shortener.redirect_code", 302)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+	switch redirectCode {
+	case 301, 302, 307, 308:
+	default:
+		log.Error().Msgf("Unsupported shortener.redirect_code: %d", redirectCode)
+		return nil, fmt.Errorf("shortener.redirect_code must be one of 301, 302, 307, 308")
+	}
+
+	prelaunch := cfg.GetString("shortener.prelaunch")
+	switch prelaunch {
+	case "":
+		prelaunch = "not_found"
+	case "not_found", "coming_soon":
+	default:
+		// fallback задаётся только у ссылки: ему нужен собственный prelaunch_url
+		log.Error().Msgf("Unsupported shortener.prelaunch: %s", prelaunch)
+		return nil, fmt.Errorf("shortener.prelaunch must be not_found or coming_soon")
+	}
+
+	qrCacheTTL, err := durationOrDefault(cfg, "shortener.qr_cache_ttl", 24*time.Hour)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, err
+	}
+
+	baseURL := strings.TrimRight(cfg.GetString("shortener.base_url"), "/")
+	if baseURL != "" {
+		if u, err := url.Parse(baseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			log.Error().Msgf("Invalid shortener.base_url: %s", baseURL)
+			return nil, fmt.Errorf("shortener.base_url must be an absolute URL like https://sho.rt")
+		}
+	}
+
+	// дополнительные зарезервированные слова через запятую: "promo,shop"
+	var reserved []string
+	for _, word := range strings.Split(cfg.GetString("shortener.reserved_words"), ",") {
+		if word = strings.TrimSpace(word); word != "" {
+			reserved = append(reserved, word)
+		}
+	}
+
+	log.Info().Msgf("Shortener config: dedup=%t idempotency_ttl=%s batch_max_items=%d redirect_code=%d prelaunch=%s base_url=%q",
+		dedup, idempotencyTTL, batchMaxItems, redirectCode, prelaunch, baseURL)
+
+	return &ShortenerConfig{
+		Dedup:          dedup,
+		IdempotencyTTL: idempotencyTTL,
+		BatchMaxItems:  batchMaxItems,
+		RedirectCode:   redirectCode,
+		PrelaunchMode:  prelaunch,
+		ComingSoonURL:  cfg.GetString("shortener.coming_soon_url"),
+		ExpiredURL:     cfg.GetString("shortener.expired_url"),
+		NotFoundURL:    cfg.GetString("shortener.not_found_url"),
+		BaseURL:        baseURL,
+		QRCacheTTL:     qrCacheTTL,
+		ReservedWords:  reserved,
+	}, nil
+}
+
+// boolOrDefault читает логическое значение, пустое значение заменяется на def
+func boolOrDefault(cfg *config.Config, key string, def bool) (bool, error) {
+	v := cfg.GetString(key)
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+// durationOrDefault читает длительность вида "24h", пустое значение заменяется на def.
+// Все длительности в конфиге — сроки, таймауты и интервалы, поэтому 0 и отрицательные значения отклоняются.
+func durationOrDefault(cfg *config.Config, key string, def time.Duration) (time.Duration, error) {
+	v := cfg.GetString(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid %s: must be positive, got %s", key, v)
+	}
+	return d, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"secondOne/cmd/buildCFG"
+	"secondOne/internal/seed"
+	"secondOne/pkg/migrator"
+	"strconv"
+	"text/tabwriter"
+)
+
+const usage = `usage:
+  myapp                      start the server (pending migrations are applied on boot)
+  myapp migrate up [N]       apply all or the next N pending migrations
+  myapp migrate down [N]     roll back the last N applied migrations (default 1)
+  myapp migrate status       show applied and pending migrations
+  myapp seed fixtures        load SQL fixtures from seed.fixtures_dir (needs seed.enabled)
+  myapp seed synthetic [-links N] [-clicks N] [-days N] [-workspace ID]
+                             generate synthetic links and click history (needs seed.enabled)`
+
+// commandEnv — зависимости, доступные подкомандам
+type commandEnv struct {
+	migrations *migrator.Migrator
+	seeder     *seed.Seeder
+	seedCfg    *buildCFG.SeedConfig
+}
+
+func runCommand(ctx context.Context, args []string, env commandEnv) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(ctx, args[1:], env.migrations)
+	case "seed":
+		return runSeed(ctx, args[1:], env)
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+func runMigrate(ctx context.Context, args []string, migrations *migrator.Migrator) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate action\n%s", usage)
+	}
+
+	switch args[0] {
+	case "up":
+		n, err := countArg(args[1:], 0)
+		if err != nil {
+			return err
+		}
+		applied, err := migrations.Up(ctx, n)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migration(s)\n", applied)
+
+	case "down":
+		n, err := countArg(args[1:], 1)
+		if err != nil {
+			return err
+		}
+		rolledBack, err := migrations.Down(ctx, n)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("rolled back %d migration(s)\n", rolledBack)
+
+	case "status":
+		statuses, err := migrations.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, st := range statuses {
+			appliedAt := "pending"
+			if st.AppliedAt != nil {
+				appliedAt = st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%06d\t%s\t%s\n", st.Version, st.Name, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("unknown migrate action %q\n%s", args[0], usage)
+	}
+
+	return nil
+}
+
+func runSeed(ctx context.Context, args []string, env commandEnv) error {
+	if !env.seedCfg.Enabled {
+		return fmt.Errorf("seeding is disabled, set seed.enabled: true in config")
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("missing seed action\n%s", usage)
+	}
+
+	switch args[0] {
+	case "fixtures":
+		loaded, err := env.seeder.LoadFixtures(ctx, env.seedCfg.FixturesDir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("loaded %d fixture file(s) from %s\n", loaded, env.seedCfg.FixturesDir)
+
+	case "synthetic":
+		opts := seed.Options{}
+		fs := flag.NewFlagSet("seed synthetic", flag.ContinueOnError)
+		fs.IntVar(&opts.Links, "links", env.seedCfg.Links, "number of links to generate")
+		fs.IntVar(&opts.ClicksPerLink, "clicks", env.seedCfg.ClicksPerLink, "average clicks per link")
+		fs.IntVar(&opts.Days, "days", env.seedCfg.Days, "history depth in days")
+		fs.Int64Var(&opts.WorkspaceID, "workspace", 0, "owner workspace id (default workspace if 0)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		links, clicks, err := env.seeder.Generate(ctx, opts)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("generated %d link(s) and %d click(s)\n", links, clicks)
+
+	default:
+		return fmt.Errorf("unknown seed action %q\n%s", args[0], usage)
+	}
+
+	return nil
+}
+
+// countArg разбирает необязательный аргумент N
+func countArg(args []string, def int) (int, error) {
+	if len(args) == 0 {
+		return def, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid number of migrations %q", args[0])
+	}
+	return n, nil
+}
@@ -13,7 +13,12 @@ import (
 	"secondOne/cmd/buildCFG"
 	"secondOne/internal/api"
 	"secondOne/internal/repo"
+	"secondOne/internal/seed"
 	"secondOne/internal/service"
+	"secondOne/pkg/geoip"
+	"secondOne/pkg/migrator"
+	"secondOne/pkg/screening"
+	"secondOne/pkg/shortcode"
 	"syscall"
 	"time"
 )
@@ -43,12 +48,42 @@ func main() {
 	}
 	log.Info().Msg("Database connected successfully")
 
+	ctx := context.Background()
+	cwd, err := os.Getwd()
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot get working directory")
+	}
+	migrationPath := filepath.Join(cwd, "migrations/postgres")
+	migrations := migrator.New(db.Master, migrationPath, &log)
+
+	// Подкоманды (например, "migrate status") выполняются вместо запуска сервера
+	if len(os.Args) > 1 {
+		seedCfg, err := buildCFG.BuildSeedConfig(cfg, &log)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to load seed config")
+		}
+		env := commandEnv{
+			migrations: migrations,
+			seeder:     seed.New(db.Master, &log),
+			seedCfg:    seedCfg,
+		}
+		if err := runCommand(ctx, os.Args[1:], env); err != nil {
+			log.Fatal().Err(err).Msg("command failed")
+		}
+		return
+	}
+
+	applied, err := migrations.Up(ctx, 0)
+	if err != nil {
+		log.Fatal().Err(err).Msg("migration failed")
+	}
+	log.Info().Msgf("Migrations applied successfully (%d new)", applied)
+
 	redisCfg, err := buildCFG.BuildRedisConfig(cfg, &log)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to load Redis config")
 	}
 	rdb := redis.New(redisCfg.Addr, redisCfg.Password, redisCfg.DB)
-	ctx := context.Background()
 	if err := rdb.Ping(ctx).Err(); err != nil {
 		log.Fatal().Msgf("failed to ping Redis: %v", err)
 	}
@@ -58,18 +93,80 @@ func main() {
 	if err != nil {
 		log.Fatal().Msgf("failed to initialize repository: %v", err)
 	}
-	cwd, err := os.Getwd()
+	codesCfg, err := buildCFG.BuildShortCodeConfig(cfg, &log)
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot get working directory")
+		log.Fatal().Err(err).Msg("failed to load short code config")
 	}
-	migrationPath := filepath.Join(cwd, "migrations/postgres")
-	if err := repository.MigrateUp(migrationPath); err != nil {
-		log.Fatal().Err(err).Msg("migration failed")
+	codes, err := shortcode.New(codesCfg, repository)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize short code generator")
+	}
+
+	shortenerCfg, err := buildCFG.BuildShortenerConfig(cfg, &log)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load shortener config")
 	}
-	log.Info().Msg("Migrations applied successfully")
 
-	serviceInstance := service.NewService(repository, &log, rdb)
-	app := api.NewRouters(&api.Routers{Service: serviceInstance})
+	geoCfg, err := buildCFG.BuildGeoIPConfig(cfg, &log)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load GeoIP config")
+	}
+	var geo service.GeoLocator
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	if geoCfg.Path != "" {
+		resolver := geoip.New(geoCfg.Path, &log)
+		go resolver.Watch(watchCtx, geoCfg.ReloadInterval)
+		geo = resolver
+	}
+
+	screeningCfg, err := buildCFG.BuildScreeningConfig(cfg, &log)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load screening config")
+	}
+	var checkers []screening.Checker
+	if screeningCfg.HookURL != "" {
+		checkers = append(checkers, screening.NewHTTPChecker(screeningCfg.HookURL, screeningCfg.HookTimeout))
+	}
+	screener := screening.New(screening.Config{
+		Schemes:       screeningCfg.Schemes,
+		AllowPrivate:  screeningCfg.AllowPrivate,
+		ResolveHosts:  screeningCfg.ResolveHosts,
+		BlocklistPath: screeningCfg.BlocklistPath,
+	}, &log, checkers...)
+	if screeningCfg.BlocklistPath != "" {
+		go screener.Watch(watchCtx, screeningCfg.ReloadInterval)
+	}
+
+	passwordCfg, err := buildCFG.BuildPasswordConfig(cfg, &log)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load password config")
+	}
+
+	serviceInstance := service.NewService(repository, &log, rdb, codes, geo, screener, service.Config{
+		ShortCodeAttempts:     codesCfg.MaxAttempts,
+		Dedup:                 shortenerCfg.Dedup,
+		BatchMaxItems:         shortenerCfg.BatchMaxItems,
+		RedirectCode:          shortenerCfg.RedirectCode,
+		PrelaunchMode:         shortenerCfg.PrelaunchMode,
+		ComingSoonURL:         shortenerCfg.ComingSoonURL,
+		ExpiredURL:            shortenerCfg.ExpiredURL,
+		NotFoundURL:           shortenerCfg.NotFoundURL,
+		BaseURL:               shortenerCfg.BaseURL,
+		QRCacheTTL:            shortenerCfg.QRCacheTTL,
+		ReservedWords:         shortenerCfg.ReservedWords,
+		PasswordSecret:        passwordCfg.Secret,
+		PasswordCookieTTL:     passwordCfg.CookieTTL,
+		PasswordMaxAttempts:   passwordCfg.MaxAttempts,
+		PasswordAttemptWindow: passwordCfg.AttemptWindow,
+	})
+	app := api.NewRouters(&api.Routers{
+		Service:        serviceInstance,
+		AdminToken:     serverCfg.Token,
+		Redis:          rdb,
+		IdempotencyTTL: shortenerCfg.IdempotencyTTL,
+		TrustedProxies: serverCfg.TrustedProxies,
+	})
 
 	serverErrChan := make(chan error, 1)
 	go func() {
@@ -97,10 +194,5 @@ func main() {
 		}
 	}
 
-	log.Info().Msg("Rolling back migrations...")
-	if err := repository.MigrateDown(migrationPath); err != nil {
-		log.Fatal().Msgf("failed to rollback migrations: %v", err)
-	}
-	log.Info().Msg("Migrations rolled back successfully")
 	log.Info().Msg("Shutdown complete")
 }
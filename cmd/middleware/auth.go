@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"github.com/wb-go/wbf/zlog"
+	"secondOne/internal/dto"
+	"secondOne/internal/service"
+	"strings"
+)
+
+type KeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (*service.APIKey, error)
+}
+
+// APIKeyMiddleware пропускает запрос только с действующим API-ключом
+// в заголовке "Authorization: Bearer <key>" или "X-API-Key".
+func APIKeyMiddleware(auth KeyAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := extractKey(c)
+		if rawKey == "" {
+			dto.UnauthorizedError(c)
+			c.Abort()
+			return
+		}
+
+		key, err := auth.AuthenticateAPIKey(c.Request.Context(), rawKey)
+		if err != nil {
+			zlog.Logger.Error().Msgf("API key check failed: %v", err)
+			dto.InternalServerError(c)
+			c.Abort()
+			return
+		}
+		if key == nil {
+			dto.UnauthorizedError(c)
+			c.Abort()
+			return
+		}
+
+		c.Set(service.APIKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// AdminMiddleware защищает управление ключами токеном server.token.
+// При пустом токене админские ручки недоступны.
+func AdminMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := extractKey(c)
+		if token == "" || provided == "" {
+			dto.UnauthorizedError(c)
+			c.Abort()
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			dto.ForbiddenError(c)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func extractKey(c *gin.Context) string {
+	if h := c.GetHeader("Authorization"); h != "" {
+		if len(h) > len("Bearer ") && strings.EqualFold(h[:len("Bearer ")], "Bearer ") {
+			return strings.TrimSpace(h[len("Bearer "):])
+		}
+	}
+	return strings.TrimSpace(c.GetHeader("X-API-Key"))
+}
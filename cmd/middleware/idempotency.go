@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/wb-go/wbf/redis"
+	"github.com/wb-go/wbf/zlog"
+	"io"
+	"secondOne/internal/dto"
+	"secondOne/internal/service"
+	"time"
+)
+
+const (
+	IdempotencyHeader = "Idempotency-Key"
+
+	maxIdempotencyKeyLen = 255
+	// пока запрос обрабатывается, ключ занят не дольше этого времени
+	idempotencyLockTTL = time.Minute
+)
+
+type idempotencyRecord struct {
+	Pending     bool   `json:"pending,omitempty"`
+	RequestHash string `json:"request_hash"`
+	Status      int    `json:"status,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Idempotency запоминает ответ на запрос с заголовком Idempotency-Key на ttl
+// и при повторе того же запроса отдаёт сохранённый ответ, не выполняя его снова.
+// Ключи разделены по workspace, поэтому middleware ставится после APIKeyMiddleware.
+func Idempotency(rdb *redis.Client, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if rdb == nil || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			dto.FieldIncorrectError(c, IdempotencyHeader)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			dto.BadResponseError(c, dto.FieldBadFormat, "Invalid request body")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(append([]byte(c.Request.Method+" "+c.FullPath()+"\n"), body...))
+		requestHash := hex.EncodeToString(sum[:])
+
+		workspaceID := int64(0)
+		if v, ok := c.Get(service.APIKeyContextKey); ok {
+			if apiKey, ok := v.(*service.APIKey); ok {
+				workspaceID = apiKey.WorkspaceID
+			}
+		}
+		redisKey := fmt.Sprintf("idem:%d:%s", workspaceID, key)
+
+		pending, _ := json.Marshal(idempotencyRecord{Pending: true, RequestHash: requestHash})
+		acquired, err := rdb.Client.SetNX(c, redisKey, pending, idempotencyLockTTL).Result()
+		if err != nil {
+			// без Redis идемпотентность не гарантируется, но запрос не блокируем
+			zlog.Logger.Warn().Msgf("Idempotency store unavailable: %v", err)
+			c.Next()
+			return
+		}
+
+		if !acquired {
+			replayStored(c, rdb, redisKey, requestHash)
+			return
+		}
+
+		writer := &captureWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		status := writer.Status()
+		if status >= 500 {
+			// ошибку сервера клиент должен иметь возможность повторить
+			if err := rdb.Client.Del(c, redisKey).Err(); err != nil {
+				zlog.Logger.Warn().Msgf("Failed to release idempotency key: %v", err)
+			}
+			return
+		}
+
+		record, _ := json.Marshal(idempotencyRecord{
+			RequestHash: requestHash,
+			Status:      status,
+			Body:        writer.body.Bytes(),
+		})
+		if err := rdb.Client.Set(c, redisKey, record, ttl).Err(); err != nil {
+			zlog.Logger.Warn().Msgf("Failed to store idempotent response: %v", err)
+		}
+	}
+}
+
+func replayStored(c *gin.Context, rdb *redis.Client, redisKey, requestHash string) {
+	data, err := rdb.Get(c, redisKey)
+	if err != nil {
+		// запись успела истечь между SETNX и GET — просим клиента повторить
+		dto.IdempotencyInProgressError(c)
+		c.Abort()
+		return
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		zlog.Logger.Error().Msgf("Corrupted idempotency record %s: %v", redisKey, err)
+		dto.InternalServerError(c)
+		c.Abort()
+		return
+	}
+
+	switch {
+	case record.RequestHash != requestHash:
+		dto.IdempotencyKeyReusedError(c)
+	case record.Pending:
+		dto.IdempotencyInProgressError(c)
+	default:
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(record.Status, "application/json; charset=utf-8", record.Body)
+	}
+	c.Abort()
+}
+
+type captureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
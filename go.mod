@@ -7,8 +7,11 @@ require (
 	github.com/go-playground/validator v9.31.0+incompatible
 	github.com/lib/pq v1.10.9
 	github.com/mssola/useragent v1.0.0
+	github.com/oschwald/maxminddb-golang v1.12.0
 	github.com/rs/zerolog v1.30.0
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/wb-go/wbf v0.0.1
+	golang.org/x/crypto v0.16.0
 )
 
 require (
@@ -48,7 +51,6 @@ require (
 	go.uber.org/atomic v1.9.0 // indirect
 	go.uber.org/multierr v1.9.0 // indirect
 	golang.org/x/arch v0.3.0 // indirect
-	golang.org/x/crypto v0.16.0 // indirect
 	golang.org/x/exp v0.0.0-20230905200255-921286631fa9 // indirect
 	golang.org/x/net v0.19.0 // indirect
 	golang.org/x/sys v0.15.0 // indirect
@@ -2,24 +2,66 @@ package api
 
 import (
 	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/redis"
 	"secondOne/cmd/middleware"
+	"secondOne/internal/dto"
 	"secondOne/internal/service"
+	"time"
 )
 
 type Routers struct {
-	Service service.Service
+	Service        service.Service
+	AdminToken     string
+	Redis          *redis.Client
+	IdempotencyTTL time.Duration
+	TrustedProxies []string // пусто — X-Forwarded-For игнорируется
 }
 
 func NewRouters(r *Routers) *ginext.Engine {
 	app := ginext.New()
+	// ClientIP считает попытки ввода пароля и пишется в клики: без явного списка прокси
+	// клиент подставил бы любой адрес в X-Forwarded-For. Адреса проверены в BuildServerConfig.
+	_ = app.SetTrustedProxies(r.TrustedProxies)
 
 	app.Use(middleware.LoggingMiddleware())
 
 	apiGroup := app.Group("/v1")
 
-	apiGroup.POST("/shorten", r.Service.CreateUrl)
+	// Переход по короткой ссылке остаётся публичным
 	apiGroup.GET("/s/:short_url", r.Service.Redirect)
-	apiGroup.GET("/analytics/:short_url", r.Service.ShowAnalytics)
+	apiGroup.POST("/s/:short_url", r.Service.UnlockLink) // форма пароля защищённой ссылки
+
+	protected := apiGroup.Group("", middleware.APIKeyMiddleware(r.Service))
+	protected.POST("/shorten", middleware.Idempotency(r.Redis, r.IdempotencyTTL), r.Service.CreateUrl)
+	protected.POST("/shorten/batch", middleware.Idempotency(r.Redis, r.IdempotencyTTL), r.Service.CreateUrlBatch)
+	protected.GET("/analytics/:short_url", r.Service.ShowAnalytics)
+	protected.GET("/links", r.Service.ListLinks)
+	protected.POST("/links/import", r.Service.ImportLinks)
+	protected.GET("/links/export", r.Service.ExportLinks)
+	protected.PATCH("/links/:short", r.Service.UpdateLink)
+	protected.DELETE("/links/:short", r.Service.DeleteLink)
+	protected.GET("/links/:short/qr", r.Service.QRCode)
+
+	admin := apiGroup.Group("/admin", middleware.AdminMiddleware(r.AdminToken))
+	admin.POST("/workspaces", r.Service.CreateWorkspace)
+	admin.GET("/workspaces", r.Service.ListWorkspaces)
+	admin.POST("/keys", r.Service.CreateAPIKey)
+	admin.GET("/keys", r.Service.ListAPIKeys)
+	admin.DELETE("/keys/:id", r.Service.RevokeAPIKey)
+	admin.POST("/domains", r.Service.CreateDomain)
+	admin.GET("/domains", r.Service.ListDomains)
+	admin.DELETE("/domains/:id", r.Service.DeleteDomain)
+
+	// Короткие ссылки в корне: https://host/abc. Статические маршруты (/v1/..., /healthz)
+	// gin проверяет раньше параметра, а зарезервированные слова отсекает RootLink
+	app.GET("/healthz", health)
+	app.GET("/:short_url", r.Service.RootLink)
+	app.POST("/:short_url", r.Service.RootLink) // форма пароля защищённой ссылки
 
 	return app
 }
+
+// health — проверка живости для балансировщика и оркестратора
+func health(c *ginext.Context) {
+	dto.SuccessResponse(c, nil)
+}
@@ -12,6 +12,26 @@ const (
 
 	ShortAlreadyExists = "SHORT_ALREADY_EXISTS"
 	ShortNotFound      = "SHORT_NOT_FOUND"
+	ShortReserved      = "SHORT_RESERVED"
+	ShortExpired       = "SHORT_EXPIRED"
+	LinkExhausted      = "LINK_EXHAUSTED"
+
+	Unauthorized   = "UNAUTHORIZED"
+	Forbidden      = "FORBIDDEN"
+	APIKeyNotFound = "API_KEY_NOT_FOUND"
+
+	WorkspaceNotFound = "WORKSPACE_NOT_FOUND"
+
+	DomainNotFound = "DOMAIN_NOT_FOUND"
+	DomainExists   = "DOMAIN_EXISTS"
+	DomainInUse    = "DOMAIN_IN_USE"
+
+	IdempotencyInProgress = "IDEMPOTENCY_IN_PROGRESS"
+	IdempotencyKeyReused  = "IDEMPOTENCY_KEY_REUSED"
+
+	BatchRejected = "BATCH_REJECTED"
+
+	UrlRejected = "URL_REJECTED"
 )
 
 type CreateShortRequest struct {
@@ -45,6 +65,16 @@ type Error struct {
 	Desc string `json:"desc"`
 }
 
+func ErrorResponse(c *ginext.Context, status int, code, desc string) {
+	c.JSON(status, Response{
+		Status: "error",
+		Error: &Error{
+			Code: code,
+			Desc: desc,
+		},
+	})
+}
+
 func BadResponseError(c *ginext.Context, code, desc string) {
 	c.JSON(400, Response{
 		Status: "error",
@@ -81,6 +111,46 @@ func ShortNotFoundError(c *ginext.Context) {
 	BadResponseError(c, ShortNotFound, "Short link not found")
 }
 
+func UnauthorizedError(c *ginext.Context) {
+	ErrorResponse(c, 401, Unauthorized, "Valid API key required")
+}
+
+func ForbiddenError(c *ginext.Context) {
+	ErrorResponse(c, 403, Forbidden, "Access denied")
+}
+
+func APIKeyNotFoundError(c *ginext.Context) {
+	ErrorResponse(c, 404, APIKeyNotFound, "API key not found")
+}
+
+func WorkspaceNotFoundError(c *ginext.Context) {
+	ErrorResponse(c, 404, WorkspaceNotFound, "Workspace not found")
+}
+
+func DomainNotFoundError(c *ginext.Context) {
+	ErrorResponse(c, 404, DomainNotFound, "Domain not found")
+}
+
+func IdempotencyInProgressError(c *ginext.Context) {
+	ErrorResponse(c, 409, IdempotencyInProgress, "A request with this Idempotency-Key is still being processed")
+}
+
+func IdempotencyKeyReusedError(c *ginext.Context) {
+	ErrorResponse(c, 422, IdempotencyKeyReused, "Idempotency-Key was already used with a different request")
+}
+
+// BatchRejectedError — атомарный пакет не применён; в data построчные результаты
+func BatchRejectedError(c *ginext.Context, data interface{}) {
+	c.JSON(400, Response{
+		Status: "error",
+		Error: &Error{
+			Code: BatchRejected,
+			Desc: "Batch was rolled back, see per-item errors",
+		},
+		Data: data,
+	})
+}
+
 func SuccessResponse(c *ginext.Context, data interface{}) {
 	c.JSON(200, Response{
 		Status: "ok",
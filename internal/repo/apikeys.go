@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+)
+
+const apiKeyColumns = `id, workspace_id, name, prefix, key_hash, created_at, last_used_at, revoked_at`
+
+func scanAPIKey(row rowScanner) (*APIKeyEntity, error) {
+	var key APIKeyEntity
+	if err := row.Scan(
+		&key.ID,
+		&key.WorkspaceID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.CreatedAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *repository) queryAPIKeys(ctx context.Context, query string, args ...interface{}) ([]APIKeyEntity, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []APIKeyEntity
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *repository) queryAPIKey(ctx context.Context, query string, args ...interface{}) (*APIKeyEntity, error) {
+	keys, err := r.queryAPIKeys(ctx, query, args...)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	return &keys[0], nil
+}
+
+func (r *repository) CreateAPIKey(ctx context.Context, key APIKeyEntity) (*APIKeyEntity, error) {
+	query := `
+		INSERT INTO api_keys (workspace_id, name, prefix, key_hash, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + apiKeyColumns
+
+	created, err := r.queryAPIKey(ctx, query, key.WorkspaceID, key.Name, key.Prefix, key.KeyHash, key.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert api key: %w", err)
+	}
+	if created == nil {
+		return nil, fmt.Errorf("no api key returned after insert")
+	}
+	return created, nil
+}
+
+// UseAPIKey находит активный ключ по хэшу и отмечает время использования.
+// Ключ проверяется на каждом запросе, поэтому last_used_at пишется не чаще раза в минуту;
+// в ответе — значение до обновления. Для неизвестного или отозванного ключа возвращается nil без ошибки.
+func (r *repository) UseAPIKey(ctx context.Context, keyHash string) (*APIKeyEntity, error) {
+	query := `
+		WITH key AS (
+			SELECT ` + apiKeyColumns + `
+			FROM api_keys
+			WHERE key_hash = $1 AND revoked_at IS NULL
+		), touched AS (
+			UPDATE api_keys
+			SET last_used_at = NOW()
+			WHERE id IN (SELECT id FROM key)
+			  AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+		)
+		SELECT ` + apiKeyColumns + ` FROM key`
+
+	return r.queryAPIKey(ctx, query, keyHash)
+}
+
+func (r *repository) ListAPIKeys(ctx context.Context) ([]APIKeyEntity, error) {
+	return r.queryAPIKeys(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id`)
+}
+
+// RevokeAPIKey отзывает ключ; nil без ошибки — ключ не найден или уже отозван
+func (r *repository) RevokeAPIKey(ctx context.Context, id int64) (*APIKeyEntity, error) {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING ` + apiKeyColumns
+
+	return r.queryAPIKey(ctx, query, id)
+}
@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+)
+
+const domainColumns = `id, host, workspace_id, created_at`
+
+func (r *repository) queryDomains(ctx context.Context, query string, args ...interface{}) ([]DomainEntity, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query domains: %w", err)
+	}
+	defer rows.Close()
+
+	var domains []DomainEntity
+	for rows.Next() {
+		var d DomainEntity
+		if err := rows.Scan(&d.ID, &d.Host, &d.WorkspaceID, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan domain: %w", err)
+		}
+		domains = append(domains, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return domains, nil
+}
+
+func (r *repository) CreateDomain(ctx context.Context, host string, workspaceID *int64) (*DomainEntity, error) {
+	domains, err := r.queryDomains(ctx,
+		`INSERT INTO domains (host, workspace_id) VALUES ($1, $2) RETURNING `+domainColumns, host, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert domain: %w", err)
+	}
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("no domain returned after insert")
+	}
+	return &domains[0], nil
+}
+
+func (r *repository) ListDomains(ctx context.Context) ([]DomainEntity, error) {
+	return r.queryDomains(ctx, `SELECT `+domainColumns+` FROM domains ORDER BY id`)
+}
+
+// DeleteDomain удаляет домен и возвращает его или nil, если домена нет.
+// Домен со ссылками удалить нельзя: ссылки держат внешний ключ.
+func (r *repository) DeleteDomain(ctx context.Context, id int64) (*DomainEntity, error) {
+	domains, err := r.queryDomains(ctx, `DELETE FROM domains WHERE id = $1 RETURNING `+domainColumns, id)
+	if err != nil || len(domains) == 0 {
+		return nil, err
+	}
+	return &domains[0], nil
+}
@@ -1,26 +1,254 @@
 package repo
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 type UrlEntity struct {
+	ID              int64       `db:"id"`
+	WorkspaceID     int64       `db:"workspace_id"`
+	APIKeyID        *int64      `db:"api_key_id"`
+	Short           string      `db:"short"`
+	Original        string      `db:"original"`
+	CustomAlias     *string     `db:"custom_alias"`
+	CreatedAt       time.Time   `db:"created_at"`
+	ExpiresAt       *time.Time  `db:"expires_at"`
+	DeletedAt       *time.Time  `db:"deleted_at"`
+	RedirectCode    *int        `db:"redirect_code"`    // nil — код по умолчанию из конфига
+	QueryForwarding string      `db:"query_forwarding"` // off, merge или override
+	UTM             *UTMParams  `db:"utm"`
+	Rules           TargetRules `db:"rules"`    // nil — правил нет
+	Variants        Variants    `db:"variants"` // nil — без A/B-теста
+	PasswordHash    *string     `db:"password_hash"`
+	MaxClicks       *int        `db:"max_clicks"`  // nil — без лимита
+	ClickCount      int         `db:"click_count"` // израсходованные переходы ссылки с лимитом
+	StartsAt        *time.Time  `db:"starts_at"`
+	PrelaunchMode   *string     `db:"prelaunch_mode"` // nil — режим по умолчанию из конфига
+	PrelaunchURL    *string     `db:"prelaunch_url"`
+	ExpiredURL      *string     `db:"expired_url"` // куда вести после expires_at
+	Title           *string     `db:"title"`
+	Interstitial    bool        `db:"interstitial"` // предупреждать перед уходом на адрес назначения
+	DeepLink        *DeepLink   `db:"deep_link"`    // nil — без перехода в приложение
+	DomainID        *int64      `db:"domain_id"`    // nil — домен по умолчанию
+}
+
+const (
+	PrelaunchNotFound   = "not_found"
+	PrelaunchComingSoon = "coming_soon"
+	PrelaunchFallback   = "fallback"
+)
+
+const (
+	QueryForwardingOff      = "off"
+	QueryForwardingMerge    = "merge"
+	QueryForwardingOverride = "override"
+)
+
+const (
+	ClickSourceLink = "link"
+	ClickSourceQR   = "qr"
+)
+
+// UTMParams — UTM-метки ссылки, хранятся в JSONB-колонке urls.utm
+type UTMParams struct {
+	Source   string `json:"source,omitempty"`
+	Medium   string `json:"medium,omitempty"`
+	Campaign string `json:"campaign,omitempty"`
+	Term     string `json:"term,omitempty"`
+	Content  string `json:"content,omitempty"`
+}
+
+func (p UTMParams) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+func (p *UTMParams) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	default:
+		return fmt.Errorf("unsupported utm value type %T", src)
+	}
+}
+
+// DeepLink — адреса мобильного приложения, хранятся в JSONB-колонке urls.deep_link
+type DeepLink struct {
+	IOS          string `json:"ios,omitempty"`
+	IOSStore     string `json:"ios_store,omitempty"`
+	Android      string `json:"android,omitempty"`
+	AndroidStore string `json:"android_store,omitempty"`
+}
+
+func (d DeepLink) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+func (d *DeepLink) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, d)
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	default:
+		return fmt.Errorf("unsupported deep_link value type %T", src)
+	}
+}
+
+// Variant — вариант адреса назначения в A/B-тесте, доля трафика пропорциональна весу
+type Variant struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+// Variants хранится в JSONB-колонке urls.variants, пустой список — NULL
+type Variants []Variant
+
+func (v Variants) Value() (driver.Value, error) {
+	if len(v) == 0 {
+		return nil, nil
+	}
+	return json.Marshal([]Variant(v))
+}
+
+func (v *Variants) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(data, (*[]Variant)(v))
+	case string:
+		return json.Unmarshal([]byte(data), (*[]Variant)(v))
+	default:
+		return fmt.Errorf("unsupported variants value type %T", src)
+	}
+}
+
+// TargetRule — правило таргетинга: пустые условия не проверяются
+type TargetRule struct {
+	OS        string   `json:"os,omitempty"`
+	Device    string   `json:"device,omitempty"`
+	Browser   string   `json:"browser,omitempty"`
+	Countries []string `json:"countries,omitempty"`
+	URL       string   `json:"url"`
+}
+
+// TargetRules хранится в JSONB-колонке urls.rules, пустой список — NULL
+type TargetRules []TargetRule
+
+func (r TargetRules) Value() (driver.Value, error) {
+	if len(r) == 0 {
+		return nil, nil
+	}
+	return json.Marshal([]TargetRule(r))
+}
+
+func (r *TargetRules) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, (*[]TargetRule)(r))
+	case string:
+		return json.Unmarshal([]byte(v), (*[]TargetRule)(r))
+	default:
+		return fmt.Errorf("unsupported rules value type %T", src)
+	}
+}
+
+// UrlUpdate описывает частичное изменение ссылки: nil-поля не трогаются
+type UrlUpdate struct {
+	Original          *string
+	CustomAlias       *string
+	ExpiresAt         *time.Time
+	ClearExpiresAt    bool
+	RedirectCode      *int
+	ClearRedirectCode bool // вернуть код редиректа по умолчанию
+	QueryForwarding   *string
+	UTM               *UTMParams
+	ClearUTM          bool
+	Rules             *TargetRules // пустой список удаляет правила
+	Variants          *Variants    // пустой список выключает A/B-тест
+	PasswordHash      *string
+	ClearPassword     bool
+	MaxClicks         *int
+	ClearMaxClicks    bool
+	StartsAt          *time.Time
+	ClearStartsAt     bool
+	PrelaunchMode     *string // "" — вернуть режим по умолчанию
+	PrelaunchURL      *string
+	ClearPrelaunchURL bool
+	ExpiredURL        *string
+	ClearExpiredURL   bool
+	Title             *string // "" — убрать заголовок
+	Interstitial      *bool
+	DeepLink          *DeepLink
+	ClearDeepLink     bool
+}
+
+// UrlListFilter задаёт фильтры и позицию курсора для постраничного списка ссылок
+type UrlListFilter struct {
+	WorkspaceID int64
+	AfterID     int64 // курсор: id последней ссылки предыдущей страницы, 0 — с начала
+	Limit       int
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Expired     *bool
+	Search      string
+}
+
+type UrlWithClicks struct {
+	UrlEntity
+	TotalClicks int64 `db:"total_clicks"`
+}
+
+type WorkspaceEntity struct {
+	ID        int64     `db:"id"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type DomainEntity struct {
+	ID          int64     `db:"id"`
+	Host        string    `db:"host"`
+	WorkspaceID *int64    `db:"workspace_id"` // nil — общий домен
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+type APIKeyEntity struct {
 	ID          int64      `db:"id"`
-	Short       string     `db:"short"`
-	Original    string     `db:"original"`
-	CustomAlias *string    `db:"custom_alias"`
+	WorkspaceID int64      `db:"workspace_id"`
+	Name        string     `db:"name"`
+	Prefix      string     `db:"prefix"`
+	KeyHash     string     `db:"key_hash"`
 	CreatedAt   time.Time  `db:"created_at"`
-	ExpiresAt   *time.Time `db:"expires_at"`
+	LastUsedAt  *time.Time `db:"last_used_at"`
+	RevokedAt   *time.Time `db:"revoked_at"`
 }
 
 type ClickEntity struct {
-	ID        int64     `db:"id"`
-	Short     string    `db:"short"`
-	CreatedAt time.Time `db:"created_at"`
-	IP        *string   `db:"ip"`
-	Browser   *string   `db:"browser"`
-	OS        *string   `db:"os"`
-	Device    *string   `db:"device"`
-	RawUA     *string   `db:"raw_ua"`
-	Referer   *string   `db:"referer"`
+	ID          int64     `db:"id"`
+	Short       string    `db:"short"`
+	CreatedAt   time.Time `db:"created_at"`
+	IP          *string   `db:"ip"`
+	Browser     *string   `db:"browser"`
+	OS          *string   `db:"os"`
+	Device      *string   `db:"device"`
+	RawUA       *string   `db:"raw_ua"`
+	Referer     *string   `db:"referer"`
+	UTMCampaign *string   `db:"utm_campaign"`
+	Country     *string   `db:"country"`
+	Region      *string   `db:"region"`
+	City        *string   `db:"city"`
+	Variant     *string   `db:"variant"`
+	Source      string    `db:"source"` // ClickSourceLink или ClickSourceQR
 }
 
 type UrlAnalytics struct {
@@ -29,6 +257,8 @@ type UrlAnalytics struct {
 	UniqueIPs   int64           `json:"unique_ips"`
 	UserAgents  []UserAgentStat `json:"user_agents"`
 	Period      AnalyticsPeriod `json:"period"`
+	Variants    []FieldStat     `json:"variants,omitempty"` // переходы по вариантам A/B-теста
+	Sources     []FieldStat     `json:"sources"`            // переходы по ссылке и сканирования QR-кода
 }
 
 type UrlAnalyticsByPeriod struct {
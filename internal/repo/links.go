@@ -0,0 +1,241 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// UpdateUrl применяет частичное изменение к активной ссылке с данным id и возвращает её новое состояние.
+// Если ссылка не найдена или удалена, возвращается nil без ошибки.
+func (r *repository) UpdateUrl(ctx context.Context, workspaceID, id int64, upd UrlUpdate) (*UrlEntity, error) {
+	var (
+		sets []string
+		args []interface{}
+	)
+	add := func(column string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if upd.Original != nil {
+		add("original", *upd.Original)
+	}
+	if upd.CustomAlias != nil {
+		add("custom_alias", *upd.CustomAlias)
+	}
+	if upd.ClearExpiresAt {
+		sets = append(sets, "expires_at = NULL")
+	} else if upd.ExpiresAt != nil {
+		add("expires_at", *upd.ExpiresAt)
+	}
+	if upd.ClearRedirectCode {
+		sets = append(sets, "redirect_code = NULL")
+	} else if upd.RedirectCode != nil {
+		add("redirect_code", *upd.RedirectCode)
+	}
+	if upd.QueryForwarding != nil {
+		add("query_forwarding", *upd.QueryForwarding)
+	}
+	if upd.ClearUTM {
+		sets = append(sets, "utm = NULL")
+	} else if upd.UTM != nil {
+		add("utm", *upd.UTM)
+	}
+	if upd.Rules != nil {
+		add("rules", *upd.Rules)
+	}
+	if upd.Variants != nil {
+		add("variants", *upd.Variants)
+	}
+	if upd.ClearPassword {
+		sets = append(sets, "password_hash = NULL")
+	} else if upd.PasswordHash != nil {
+		add("password_hash", *upd.PasswordHash)
+	}
+	if upd.ClearMaxClicks {
+		sets = append(sets, "max_clicks = NULL")
+	} else if upd.MaxClicks != nil {
+		add("max_clicks", *upd.MaxClicks)
+	}
+	if upd.ClearStartsAt {
+		sets = append(sets, "starts_at = NULL")
+	} else if upd.StartsAt != nil {
+		add("starts_at", *upd.StartsAt)
+	}
+	if upd.PrelaunchMode != nil {
+		if *upd.PrelaunchMode == "" {
+			sets = append(sets, "prelaunch_mode = NULL")
+		} else {
+			add("prelaunch_mode", *upd.PrelaunchMode)
+		}
+	}
+	if upd.ClearPrelaunchURL {
+		sets = append(sets, "prelaunch_url = NULL")
+	} else if upd.PrelaunchURL != nil {
+		add("prelaunch_url", *upd.PrelaunchURL)
+	}
+	if upd.ClearExpiredURL {
+		sets = append(sets, "expired_url = NULL")
+	} else if upd.ExpiredURL != nil {
+		add("expired_url", *upd.ExpiredURL)
+	}
+	if upd.Title != nil {
+		if *upd.Title == "" {
+			sets = append(sets, "title = NULL")
+		} else {
+			add("title", *upd.Title)
+		}
+	}
+	if upd.Interstitial != nil {
+		add("interstitial", *upd.Interstitial)
+	}
+	if upd.ClearDeepLink {
+		sets = append(sets, "deep_link = NULL")
+	} else if upd.DeepLink != nil {
+		add("deep_link", *upd.DeepLink)
+	}
+
+	if len(sets) == 0 {
+		return nil, fmt.Errorf("nothing to update")
+	}
+
+	args = append(args, id, workspaceID)
+	query := fmt.Sprintf(`
+		UPDATE urls
+		SET %s
+		WHERE id = $%d AND workspace_id = $%d AND deleted_at IS NULL
+		RETURNING `+urlColumns,
+		strings.Join(sets, ", "), len(args)-1, len(args))
+
+	url, err := r.queryUrl(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update url: %w", err)
+	}
+	return url, nil
+}
+
+// DeleteUrl мягко удаляет ссылку с кодом на домене: строки и клики остаются в БД для отчётов.
+// Возвращает все удалённые строки, ссылка с таким short — первой; пусто, если активной ссылки не было.
+func (r *repository) DeleteUrl(ctx context.Context, workspaceID, domainID int64, short string) ([]UrlEntity, error) {
+	query := `
+		WITH deleted AS (
+			UPDATE urls
+			SET deleted_at = NOW()
+			WHERE (short = $1 OR custom_alias = $1) AND workspace_id = $2
+			  AND COALESCE(domain_id, 0) = $3 AND deleted_at IS NULL
+			RETURNING ` + urlColumns + `
+		)
+		SELECT ` + urlColumns + ` FROM deleted
+		ORDER BY short = $1 DESC, id`
+
+	urls, err := r.queryUrls(ctx, query, short, workspaceID, domainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete url: %w", err)
+	}
+	return urls, nil
+}
+
+// ListUrls возвращает активные ссылки workspace от новых к старым вместе с общим числом кликов.
+// Пагинация курсорная: следующая страница запрашивается с AfterID последнего элемента.
+func (r *repository) ListUrls(ctx context.Context, filter UrlListFilter) ([]UrlWithClicks, error) {
+	where := []string{"u.deleted_at IS NULL", "u.workspace_id = $1"}
+	args := []interface{}{filter.WorkspaceID}
+	add := func(cond string, value interface{}) {
+		args = append(args, value)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.AfterID > 0 {
+		add("u.id < $%d", filter.AfterID)
+	}
+	if filter.CreatedFrom != nil {
+		add("u.created_at >= $%d", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		add("u.created_at < $%d", *filter.CreatedTo)
+	}
+	if filter.Expired != nil {
+		if *filter.Expired {
+			where = append(where, "u.expires_at IS NOT NULL AND u.expires_at <= NOW()")
+		} else {
+			where = append(where, "(u.expires_at IS NULL OR u.expires_at > NOW())")
+		}
+	}
+	if filter.Search != "" {
+		add("(u.original ILIKE $%[1]d OR u.custom_alias ILIKE $%[1]d)", "%"+escapeLike(filter.Search)+"%")
+	}
+
+	args = append(args, filter.Limit)
+	query := fmt.Sprintf(`
+		SELECT %s, COALESCE(c.total, 0) AS total_clicks
+		FROM urls u
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) AS total FROM clicks WHERE clicks.short = u.short
+		) c ON true
+		WHERE %s
+		ORDER BY u.id DESC
+		LIMIT $%d
+	`, qualifiedUrlColumns("u"), strings.Join(where, " AND "), len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list urls: %w", err)
+	}
+	defer rows.Close()
+
+	var result []UrlWithClicks
+	for rows.Next() {
+		var total int64
+		url, err := scanUrl(rows, &total)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan url: %w", err)
+		}
+		result = append(result, UrlWithClicks{UrlEntity: *url, TotalClicks: total})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// StreamUrls передаёт в fn все активные ссылки workspace по одной, не загружая их в память.
+// Ошибка fn прерывает обход и возвращается как есть.
+func (r *repository) StreamUrls(ctx context.Context, workspaceID int64, withClicks bool, fn func(UrlWithClicks) error) error {
+	clicks := `0`
+	if withClicks {
+		clicks = `(SELECT COUNT(*) FROM clicks WHERE clicks.short = u.short)`
+	}
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT %s, %s AS total_clicks
+		FROM urls u
+		WHERE u.workspace_id = $1 AND u.deleted_at IS NULL
+		ORDER BY u.id
+	`, qualifiedUrlColumns("u"), clicks), workspaceID)
+	if err != nil {
+		return fmt.Errorf("failed to stream urls: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var total int64
+		url, err := scanUrl(rows, &total)
+		if err != nil {
+			return fmt.Errorf("failed to scan url: %w", err)
+		}
+		if err := fn(UrlWithClicks{UrlEntity: *url, TotalClicks: total}); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -2,19 +2,38 @@ package repo
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/rs/zerolog"
 	"github.com/wb-go/wbf/dbpg"
-	"io/ioutil"
-	"path/filepath"
+	"strings"
 	"time"
 )
 
 type Repository interface {
-	MigrateUp(migrationsDir string) error
-	MigrateDown(migrationsDir string) error
+	InTx(ctx context.Context, fn func(tx Repository) error) error
 	CreateUrl(ctx context.Context, url UrlEntity) (int64, error)
-	GetUrlByShort(ctx context.Context, short string) (*UrlEntity, error)
+	NextShortSequence(ctx context.Context) (int64, error)
+	GetUrlByShort(ctx context.Context, domainID int64, short string) (*UrlEntity, error)
+	FindActiveUrlByOriginal(ctx context.Context, workspaceID, domainID int64, original string) (*UrlEntity, error)
+	AliasTaken(ctx context.Context, domainID int64, alias string) (bool, error)
+	ConsumeClick(ctx context.Context, id int64) (bool, error)
+	GetOwnedUrl(ctx context.Context, workspaceID, domainID int64, short string, includeDeleted bool) (*UrlEntity, error)
+	UpdateUrl(ctx context.Context, workspaceID, id int64, upd UrlUpdate) (*UrlEntity, error)
+	DeleteUrl(ctx context.Context, workspaceID, domainID int64, short string) ([]UrlEntity, error)
+	ListUrls(ctx context.Context, filter UrlListFilter) ([]UrlWithClicks, error)
+	StreamUrls(ctx context.Context, workspaceID int64, withClicks bool, fn func(UrlWithClicks) error) error
+	CreateWorkspace(ctx context.Context, name string) (*WorkspaceEntity, error)
+	GetWorkspace(ctx context.Context, id int64) (*WorkspaceEntity, error)
+	ListWorkspaces(ctx context.Context) ([]WorkspaceEntity, error)
+	CreateDomain(ctx context.Context, host string, workspaceID *int64) (*DomainEntity, error)
+	ListDomains(ctx context.Context) ([]DomainEntity, error)
+	DeleteDomain(ctx context.Context, id int64) (*DomainEntity, error)
+	CreateAPIKey(ctx context.Context, key APIKeyEntity) (*APIKeyEntity, error)
+	UseAPIKey(ctx context.Context, keyHash string) (*APIKeyEntity, error)
+	ListAPIKeys(ctx context.Context) ([]APIKeyEntity, error)
+	RevokeAPIKey(ctx context.Context, id int64) (*APIKeyEntity, error)
 	CreateClick(ctx context.Context, click ClickEntity) error
 	GetUrlAnalytics(ctx context.Context, short string) (*UrlAnalytics, error)
 	GetUserAgentStats(ctx context.Context, short string) ([]UserAgentStat, error)
@@ -23,10 +42,20 @@ type Repository interface {
 	GetAnalyticsByField(ctx context.Context, short string, field string) ([]FieldStat, *AnalyticsPeriod, error)
 }
 
+// ErrUrlConflict — short или алиас уже заняты
+var ErrUrlConflict = errors.New("url short or alias already exists")
+
+// querier — общее подмножество *dbpg.DB и *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
 type repository struct {
-	db  *dbpg.DB
-	log *zerolog.Logger
-	ctx context.Context
+	db   querier
+	pool *dbpg.DB // nil внутри транзакции
+	log  *zerolog.Logger
+	ctx  context.Context
 }
 
 func NewRepository(ctx context.Context, db *dbpg.DB, log *zerolog.Logger) (Repository, error) {
@@ -38,58 +67,46 @@ func NewRepository(ctx context.Context, db *dbpg.DB, log *zerolog.Logger) (Repos
 	}
 
 	return &repository{
-		db:  db,
-		log: log,
-		ctx: ctx,
+		db:   db,
+		pool: db,
+		log:  log,
+		ctx:  ctx,
 	}, nil
 }
 
-func (r *repository) MigrateUp(migrationsDir string) error {
-	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.up.sql"))
-	if err != nil {
-		return fmt.Errorf("failed to read migration files: %w", err)
-	}
-
-	for _, file := range files {
-		sqlBytes, err := ioutil.ReadFile(file)
-		if err != nil {
-			return fmt.Errorf("failed to read migration file %s: %w", file, err)
-		}
-
-		if _, err := r.db.ExecContext(r.ctx, string(sqlBytes)); err != nil {
-			return fmt.Errorf("failed to apply migration %s: %w", file, err)
-		}
+// InTx выполняет fn с репозиторием, привязанным к одной транзакции.
+// Ошибка fn откатывает транзакцию; вложенный вызов использует уже открытую.
+func (r *repository) InTx(ctx context.Context, fn func(tx Repository) error) error {
+	if r.pool == nil {
+		return fn(r)
 	}
 
-	r.log.Info().Msgf("Migrations applied successfully from %s", migrationsDir)
-	return nil
-}
-
-func (r *repository) MigrateDown(migrationsDir string) error {
-	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.down.sql"))
+	tx, err := r.pool.Master.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to read rollback files: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	for _, file := range files {
-		sqlBytes, err := ioutil.ReadFile(file)
-		if err != nil {
-			return fmt.Errorf("failed to read rollback file %s: %w", file, err)
-		}
-
-		if _, err := r.db.ExecContext(r.ctx, string(sqlBytes)); err != nil {
-			return fmt.Errorf("failed to rollback migration %s: %w", file, err)
-		}
+	if err := fn(&repository{db: tx, log: r.log, ctx: r.ctx}); err != nil {
+		return err
 	}
 
-	r.log.Info().Msgf("Migrations rolled back successfully from %s", migrationsDir)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
+// CreateUrl вставляет ссылку и возвращает её id. Занятые short или алиас дают
+// ErrUrlConflict без ошибки SQL, поэтому внутри транзакции можно повторить попытку.
 func (r *repository) CreateUrl(ctx context.Context, url UrlEntity) (int64, error) {
 	query := `
-		INSERT INTO urls (short, original, custom_alias, created_at, expires_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO urls (short, original, custom_alias, created_at, expires_at, workspace_id, api_key_id, redirect_code,
+		                  query_forwarding, utm, rules, variants, password_hash, max_clicks,
+		                  starts_at, prelaunch_mode, prelaunch_url, expired_url, title, interstitial, deep_link, domain_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, COALESCE(NULLIF($9, ''), 'off'), $10, $11, $12, $13, $14,
+		        $15, $16, $17, $18, $19, $20, $21, $22)
+		ON CONFLICT DO NOTHING
 		RETURNING id
 	`
 	rows, err := r.db.QueryContext(ctx, query,
@@ -98,6 +115,23 @@ func (r *repository) CreateUrl(ctx context.Context, url UrlEntity) (int64, error
 		url.CustomAlias,
 		url.CreatedAt,
 		url.ExpiresAt,
+		url.WorkspaceID,
+		url.APIKeyID,
+		url.RedirectCode,
+		url.QueryForwarding,
+		url.UTM,
+		url.Rules,
+		url.Variants,
+		url.PasswordHash,
+		url.MaxClicks,
+		url.StartsAt,
+		url.PrelaunchMode,
+		url.PrelaunchURL,
+		url.ExpiredURL,
+		url.Title,
+		url.Interstitial,
+		url.DeepLink,
+		url.DomainID,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert url: %w", err)
@@ -110,48 +144,202 @@ func (r *repository) CreateUrl(ctx context.Context, url UrlEntity) (int64, error
 			return 0, fmt.Errorf("failed to scan returned id: %w", err)
 		}
 	} else {
-		return 0, fmt.Errorf("no id returned after insert")
+		if err := rows.Err(); err != nil {
+			return 0, fmt.Errorf("failed to insert url: %w", err)
+		}
+		return 0, ErrUrlConflict
 	}
 
 	return id, nil
 }
 
-func (r *repository) GetUrlByShort(ctx context.Context, short string) (*UrlEntity, error) {
+// NextShortSequence возвращает следующий номер для генерации коротких кодов
+func (r *repository) NextShortSequence(ctx context.Context) (int64, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT nextval('short_code_seq')`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get next short sequence: %w", err)
+	}
+	defer rows.Close()
+
+	var n int64
+	if rows.Next() {
+		if err := rows.Scan(&n); err != nil {
+			return 0, fmt.Errorf("failed to scan short sequence: %w", err)
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("no value returned from short_code_seq")
+}
+
+// GetUrlByShort ищет активную ссылку домена по short или алиасу; domainID 0 — домен по умолчанию.
+// Код на домене занят только одной ссылкой; для старых совпадений алиаса с чужим short
+// побеждает short — он был выдан раньше, и алиас не может перехватить чужую ссылку.
+func (r *repository) GetUrlByShort(ctx context.Context, domainID int64, short string) (*UrlEntity, error) {
 	query := `
-		SELECT id, short, original, custom_alias, created_at, expires_at
+		SELECT ` + urlColumns + `
 		FROM urls
-		WHERE short = $1 OR custom_alias = $1
+		WHERE (short = $1 OR custom_alias = $1) AND COALESCE(domain_id, 0) = $2 AND deleted_at IS NULL
+		ORDER BY short = $1 DESC, id
 		LIMIT 1
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, short)
+	return r.queryUrl(ctx, query, short, domainID)
+}
+
+// AliasTaken сообщает, занят ли код на домене — алиасом или сгенерированным short, в том числе удалённой ссылкой
+func (r *repository) AliasTaken(ctx context.Context, domainID int64, alias string) (bool, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT 1 FROM urls WHERE COALESCE(domain_id, 0) = $1 AND (custom_alias = $2 OR short = $2) LIMIT 1`, domainID, alias)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query url by short: %w", err)
+		return false, fmt.Errorf("failed to check alias: %w", err)
 	}
 	defer rows.Close()
 
-	if rows.Next() {
-		var url UrlEntity
-		if err := rows.Scan(
-			&url.ID,
-			&url.Short,
-			&url.Original,
-			&url.CustomAlias,
-			&url.CreatedAt,
-			&url.ExpiresAt,
-		); err != nil {
+	return rows.Next(), rows.Err()
+}
+
+// ConsumeClick атомарно расходует один переход ссылки с лимитом.
+// false — лимит исчерпан (или ссылка удалена), редиректить нельзя.
+func (r *repository) ConsumeClick(ctx context.Context, id int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE urls
+		SET click_count = click_count + 1
+		WHERE id = $1 AND deleted_at IS NULL
+		  AND (max_clicks IS NULL OR click_count < max_clicks)
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume click: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to consume click: %w", err)
+	}
+	return n == 1, nil
+}
+
+// GetOwnedUrl ищет ссылку с кодом на домене только среди ссылок workspace; includeDeleted — с учётом мягко удалённых
+func (r *repository) GetOwnedUrl(ctx context.Context, workspaceID, domainID int64, short string, includeDeleted bool) (*UrlEntity, error) {
+	query := `
+		SELECT ` + urlColumns + `
+		FROM urls
+		WHERE (short = $1 OR custom_alias = $1) AND workspace_id = $2
+		  AND COALESCE(domain_id, 0) = $3 AND ($4 OR deleted_at IS NULL)
+		ORDER BY deleted_at NULLS FIRST, short = $1 DESC, id
+		LIMIT 1
+	`
+
+	return r.queryUrl(ctx, query, short, workspaceID, domainID, includeDeleted)
+}
+
+// FindActiveUrlByOriginal ищет самую свежую простую ссылку workspace на тот же адрес на том же домене:
+// без срока, пароля, лимита переходов, расписания, правил и прочих настроек, которые
+// отличали бы её от новой ссылки без настроек
+func (r *repository) FindActiveUrlByOriginal(ctx context.Context, workspaceID, domainID int64, original string) (*UrlEntity, error) {
+	query := `
+		SELECT ` + urlColumns + `
+		FROM urls
+		WHERE original = $1 AND workspace_id = $2 AND COALESCE(domain_id, 0) = $3 AND deleted_at IS NULL
+		  AND expires_at IS NULL AND starts_at IS NULL AND expired_url IS NULL
+		  AND password_hash IS NULL AND max_clicks IS NULL
+		  AND redirect_code IS NULL AND query_forwarding = 'off' AND utm IS NULL
+		  AND rules IS NULL AND variants IS NULL AND deep_link IS NULL
+		  AND title IS NULL AND NOT interstitial
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	return r.queryUrl(ctx, query, original, workspaceID, domainID)
+}
+
+const urlColumns = `id, workspace_id, api_key_id, short, original, custom_alias, created_at, expires_at, deleted_at,
+	redirect_code, query_forwarding, utm, rules, variants, password_hash, max_clicks, click_count,
+	starts_at, prelaunch_mode, prelaunch_url, expired_url, title, interstitial, deep_link, domain_id`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// qualifiedUrlColumns возвращает urlColumns с префиксом таблицы для запросов с JOIN
+func qualifiedUrlColumns(alias string) string {
+	columns := strings.Split(urlColumns, ",")
+	for i, c := range columns {
+		columns[i] = alias + "." + strings.TrimSpace(c)
+	}
+	return strings.Join(columns, ", ")
+}
+
+// scanUrl сканирует колонки urlColumns, extra — дополнительные колонки после них
+func scanUrl(row rowScanner, extra ...interface{}) (*UrlEntity, error) {
+	var url UrlEntity
+	dest := append([]interface{}{
+		&url.ID,
+		&url.WorkspaceID,
+		&url.APIKeyID,
+		&url.Short,
+		&url.Original,
+		&url.CustomAlias,
+		&url.CreatedAt,
+		&url.ExpiresAt,
+		&url.DeletedAt,
+		&url.RedirectCode,
+		&url.QueryForwarding,
+		&url.UTM,
+		&url.Rules,
+		&url.Variants,
+		&url.PasswordHash,
+		&url.MaxClicks,
+		&url.ClickCount,
+		&url.StartsAt,
+		&url.PrelaunchMode,
+		&url.PrelaunchURL,
+		&url.ExpiredURL,
+		&url.Title,
+		&url.Interstitial,
+		&url.DeepLink,
+		&url.DomainID,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &url, nil
+}
+
+func (r *repository) queryUrls(ctx context.Context, query string, args ...interface{}) ([]UrlEntity, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query url: %w", err)
+	}
+	defer rows.Close()
+
+	var urls []UrlEntity
+	for rows.Next() {
+		url, err := scanUrl(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan url: %w", err)
 		}
-		return &url, nil
+		urls = append(urls, *url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
 	}
 
-	// ничего не найдено
-	return nil, nil
+	return urls, nil
 }
+
+func (r *repository) queryUrl(ctx context.Context, query string, args ...interface{}) (*UrlEntity, error) {
+	urls, err := r.queryUrls(ctx, query, args...)
+	if err != nil || len(urls) == 0 {
+		// ничего не найдено
+		return nil, err
+	}
+	return &urls[0], nil
+}
+
 func (r *repository) CreateClick(ctx context.Context, click ClickEntity) error {
 	query := `
-		INSERT INTO clicks (short, created_at, ip, browser, os, device, raw_ua, referer)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO clicks (short, created_at, ip, browser, os, device, raw_ua, referer, utm_campaign,
+		                    country, region, city, variant, source)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, COALESCE(NULLIF($14, ''), 'link'))
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -163,6 +351,12 @@ func (r *repository) CreateClick(ctx context.Context, click ClickEntity) error {
 		click.Device,
 		click.RawUA,
 		click.Referer,
+		click.UTMCampaign,
+		click.Country,
+		click.Region,
+		click.City,
+		click.Variant,
+		click.Source,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert click: %w", err)
@@ -283,12 +477,64 @@ func (r *repository) GetUrlAnalytics(ctx context.Context, short string) (*UrlAna
 		return nil, fmt.Errorf("rows iteration failed: %w", err)
 	}
 
+	// variant stats: только клики, прошедшие через A/B-тест
+	rows, err = r.db.QueryContext(ctx, `
+		SELECT variant, COUNT(*) AS count
+		FROM clicks
+		WHERE short = $1 AND variant IS NOT NULL
+		GROUP BY variant
+		ORDER BY count DESC
+	`, short)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variant stats: %w", err)
+	}
+	defer rows.Close()
+
+	var variants []FieldStat
+	for rows.Next() {
+		var s FieldStat
+		if err := rows.Scan(&s.Value, &s.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan variant stat: %w", err)
+		}
+		variants = append(variants, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	// source stats: переходы по ссылке против сканирований QR-кода
+	rows, err = r.db.QueryContext(ctx, `
+		SELECT source, COUNT(*) AS count
+		FROM clicks
+		WHERE short = $1
+		GROUP BY source
+		ORDER BY count DESC
+	`, short)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source stats: %w", err)
+	}
+	defer rows.Close()
+
+	var sources []FieldStat
+	for rows.Next() {
+		var s FieldStat
+		if err := rows.Scan(&s.Value, &s.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan source stat: %w", err)
+		}
+		sources = append(sources, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
 	return &UrlAnalytics{
 		Short:       short,
 		TotalClicks: totalClicks,
 		UniqueIPs:   uniqueIPs,
 		UserAgents:  stats,
 		Period:      period,
+		Variants:    variants,
+		Sources:     sources,
 	}, nil
 }
 
@@ -440,7 +686,9 @@ func (r *repository) GetAnalyticsByMonth(ctx context.Context, short string, mont
 
 // GetAnalyticsByField агрегирует по одному полю (browser/os/device) за всю историю
 func (r *repository) GetAnalyticsByField(ctx context.Context, short string, field string) ([]FieldStat, *AnalyticsPeriod, error) {
-	if field != "browser" && field != "os" && field != "device" {
+	switch field {
+	case "browser", "os", "device", "utm_campaign", "country", "region", "city", "variant", "source":
+	default:
 		err := fmt.Errorf("unsupported field for aggregation: %s", field)
 		r.log.Error().Msgf("%v", err)
 		return nil, nil, err
@@ -0,0 +1,54 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+)
+
+func (r *repository) queryWorkspaces(ctx context.Context, query string, args ...interface{}) ([]WorkspaceEntity, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspaces: %w", err)
+	}
+	defer rows.Close()
+
+	var workspaces []WorkspaceEntity
+	for rows.Next() {
+		var w WorkspaceEntity
+		if err := rows.Scan(&w.ID, &w.Name, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan workspace: %w", err)
+		}
+		workspaces = append(workspaces, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return workspaces, nil
+}
+
+func (r *repository) CreateWorkspace(ctx context.Context, name string) (*WorkspaceEntity, error) {
+	workspaces, err := r.queryWorkspaces(ctx,
+		`INSERT INTO workspaces (name) VALUES ($1) RETURNING id, name, created_at`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert workspace: %w", err)
+	}
+	if len(workspaces) == 0 {
+		return nil, fmt.Errorf("no workspace returned after insert")
+	}
+	return &workspaces[0], nil
+}
+
+// GetWorkspace возвращает workspace по id или nil, если его нет
+func (r *repository) GetWorkspace(ctx context.Context, id int64) (*WorkspaceEntity, error) {
+	workspaces, err := r.queryWorkspaces(ctx,
+		`SELECT id, name, created_at FROM workspaces WHERE id = $1`, id)
+	if err != nil || len(workspaces) == 0 {
+		return nil, err
+	}
+	return &workspaces[0], nil
+}
+
+func (r *repository) ListWorkspaces(ctx context.Context) ([]WorkspaceEntity, error) {
+	return r.queryWorkspaces(ctx, `SELECT id, name, created_at FROM workspaces ORDER BY id`)
+}
@@ -0,0 +1,305 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/rs/zerolog"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	linksBatch  = 500
+	clicksBatch = 1000
+	shortLen    = 7
+	alphabet    = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// Options задаёт объём синтетических данных
+type Options struct {
+	Links         int
+	ClicksPerLink int   // среднее число кликов на ссылку, распределение с длинным хвостом
+	Days          int   // глубина истории: ссылки и клики распределяются по последним Days дням
+	WorkspaceID   int64 // 0 — workspace по умолчанию
+}
+
+type Seeder struct {
+	db  *sql.DB
+	log *zerolog.Logger
+}
+
+func New(db *sql.DB, log *zerolog.Logger) *Seeder {
+	return &Seeder{
+		db:  db,
+		log: log,
+	}
+}
+
+// LoadFixtures выполняет все *.sql из каталога по алфавиту, каждый файл в своей транзакции
+func (s *Seeder) LoadFixtures(ctx context.Context, dir string) (int, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read fixtures dir %s: %w", dir, err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		sqlBytes, err := os.ReadFile(file)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read fixture %s: %w", file, err)
+		}
+
+		err = s.inTx(ctx, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, string(sqlBytes))
+			return err
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to load fixture %s: %w", file, err)
+		}
+		s.log.Info().Msgf("Fixture %s loaded", filepath.Base(file))
+	}
+
+	return len(files), nil
+}
+
+// Generate создаёт синтетические ссылки и историю кликов по ним в одной транзакции
+func (s *Seeder) Generate(ctx context.Context, opts Options) (links, clicks int, err error) {
+	if opts.Links <= 0 {
+		return 0, 0, fmt.Errorf("number of links must be positive")
+	}
+	if opts.Days <= 0 {
+		opts.Days = 1
+	}
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		workspaceID := opts.WorkspaceID
+		if workspaceID == 0 {
+			if err := tx.QueryRowContext(ctx, `SELECT MIN(id) FROM workspaces`).Scan(&workspaceID); err != nil {
+				return fmt.Errorf("failed to get default workspace: %w", err)
+			}
+		}
+
+		now := time.Now()
+		for done := 0; done < opts.Links; done += linksBatch {
+			n := min(linksBatch, opts.Links-done)
+
+			created, err := insertLinks(ctx, tx, workspaceID, n, now, opts.Days)
+			if err != nil {
+				return err
+			}
+			links += len(created)
+
+			var pending []syntheticClick
+			for _, link := range created {
+				for range clicksFor(opts.ClicksPerLink) {
+					pending = append(pending, randomClick(link, now))
+					if len(pending) == clicksBatch {
+						if err := insertClicks(ctx, tx, pending); err != nil {
+							return err
+						}
+						clicks += len(pending)
+						pending = pending[:0]
+					}
+				}
+			}
+			if err := insertClicks(ctx, tx, pending); err != nil {
+				return err
+			}
+			clicks += len(pending)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	s.log.Info().Msgf("Generated %d synthetic links with %d clicks", links, clicks)
+	return links, clicks, nil
+}
+
+func (s *Seeder) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+type createdLink struct {
+	short     string
+	createdAt time.Time
+}
+
+func insertLinks(ctx context.Context, tx *sql.Tx, workspaceID int64, n int, now time.Time, days int) ([]createdLink, error) {
+	var (
+		values []string
+		args   []interface{}
+	)
+	for i := 0; i < n; i++ {
+		createdAt := now.Add(-time.Duration(rand.Int64N(int64(days) * int64(24*time.Hour))))
+		var expiresAt *time.Time
+		if rand.IntN(5) == 0 {
+			// каждая пятая ссылка с ограниченным сроком, часть уже истекла
+			t := createdAt.Add(time.Duration(rand.IntN(60)+1) * 24 * time.Hour)
+			expiresAt = &t
+		}
+
+		base := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d)", base+1, base+2, base+3, base+4, base+5))
+		args = append(args, randomShort(), randomDestination(), createdAt, expiresAt, workspaceID)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		INSERT INTO urls (short, original, created_at, expires_at, workspace_id)
+		VALUES `+strings.Join(values, ", ")+`
+		ON CONFLICT DO NOTHING
+		RETURNING short, created_at
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert synthetic links: %w", err)
+	}
+	defer rows.Close()
+
+	var created []createdLink
+	for rows.Next() {
+		var link createdLink
+		if err := rows.Scan(&link.short, &link.createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan synthetic link: %w", err)
+		}
+		created = append(created, link)
+	}
+	return created, rows.Err()
+}
+
+type syntheticClick struct {
+	short     string
+	createdAt time.Time
+	ip        string
+	referer   *string
+	profile   uaProfile
+}
+
+func insertClicks(ctx context.Context, tx *sql.Tx, clicks []syntheticClick) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+
+	var (
+		values []string
+		args   []interface{}
+	)
+	for _, c := range clicks {
+		base := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8))
+		args = append(args, c.short, c.createdAt, c.ip, c.referer, c.profile.browser, c.profile.os, c.profile.device, c.profile.ua)
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO clicks (short, created_at, ip, referer, browser, os, device, raw_ua)
+		VALUES `+strings.Join(values, ", "), args...)
+	if err != nil {
+		return fmt.Errorf("failed to insert synthetic clicks: %w", err)
+	}
+	return nil
+}
+
+// clicksFor возвращает число кликов с экспоненциальным распределением:
+// большинство ссылок почти без переходов, немногие — популярные
+func clicksFor(avg int) int {
+	if avg <= 0 {
+		return 0
+	}
+	return int(rand.ExpFloat64() * float64(avg))
+}
+
+func randomClick(link createdLink, now time.Time) syntheticClick {
+	span := now.Sub(link.createdAt)
+	if span <= 0 {
+		span = time.Minute
+	}
+
+	click := syntheticClick{
+		short:     link.short,
+		createdAt: link.createdAt.Add(time.Duration(rand.Int64N(int64(span)))),
+		ip:        fmt.Sprintf("%d.%d.%d.%d", rand.IntN(223)+1, rand.IntN(256), rand.IntN(256), rand.IntN(254)+1),
+		profile:   pickProfile(),
+	}
+	if ref := referers[rand.IntN(len(referers))]; ref != "" {
+		click.referer = &ref
+	}
+	return click
+}
+
+func randomShort() string {
+	b := make([]byte, shortLen)
+	for i := range b {
+		b[i] = alphabet[rand.IntN(len(alphabet))]
+	}
+	return string(b)
+}
+
+func randomDestination() string {
+	host := hosts[rand.IntN(len(hosts))]
+	path := paths[rand.IntN(len(paths))]
+	return fmt.Sprintf("https://%s/%s/%d", host, path, rand.IntN(10000))
+}
+
+var hosts = []string{
+	"example.com", "shop.example.com", "blog.example.org", "news.example.net",
+	"docs.example.io", "landing.example.com", "events.example.org",
+}
+
+var paths = []string{
+	"products", "articles", "promo", "signup", "webinar", "pricing", "careers", "help",
+}
+
+// пустая строка — прямой переход без Referer
+var referers = []string{
+	"", "", "https://google.com", "https://google.com", "https://bing.com",
+	"https://t.me", "https://vk.com", "https://twitter.com", "https://duckduckgo.com",
+}
+
+type uaProfile struct {
+	weight  int
+	browser string
+	os      string
+	device  string
+	ua      string
+}
+
+var profiles = []uaProfile{
+	{35, "Chrome", "Windows 10", "Desktop", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"},
+	{20, "Chrome", "Android 13", "Mobile", "Mozilla/5.0 (Linux; Android 13; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36"},
+	{18, "Safari", "CPU iPhone OS 17_1 like Mac OS X", "Mobile", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1"},
+	{8, "Safari", "Intel Mac OS X 10_15_7", "Desktop", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15"},
+	{7, "Firefox", "Linux x86_64", "Desktop", "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"},
+	{7, "Edge", "Windows 10", "Desktop", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0"},
+	{3, "Chrome", "Windows 10", "Desktop", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 YaBrowser/23.11.0.0 Safari/537.36"},
+	{2, "Googlebot", "", "Bot", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"},
+}
+
+func pickProfile() uaProfile {
+	total := 0
+	for _, p := range profiles {
+		total += p.weight
+	}
+	n := rand.IntN(total)
+	for _, p := range profiles {
+		if n < p.weight {
+			return p
+		}
+		n -= p.weight
+	}
+	return profiles[0]
+}
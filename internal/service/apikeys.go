@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/wb-go/wbf/ginext"
+	"secondOne/internal/dto"
+	"secondOne/internal/repo"
+	"secondOne/pkg/validator"
+	"strconv"
+	"time"
+)
+
+// APIKeyContextKey — ключ gin-контекста, под которым middleware кладёт аутентифицированный *APIKey
+const APIKeyContextKey = "api_key"
+
+const (
+	apiKeyPrefix      = "sk_"
+	apiKeyRandomBytes = 32
+	apiKeyShownChars  = 8
+)
+
+func (s *service) CreateAPIKey(ctx *ginext.Context) {
+	var req struct {
+		WorkspaceID int64  `json:"workspace_id" validate:"required,gt=0"`
+		Name        string `json:"name" validate:"required,max=100"`
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		s.log.Error().Msgf("Invalid request body: %v", err)
+		dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+		return
+	}
+
+	if err := validator.Validate(ctx.Request.Context(), req); err != nil {
+		dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
+		return
+	}
+
+	workspace, err := s.repo.GetWorkspace(ctx.Request.Context(), req.WorkspaceID)
+	if err != nil {
+		s.log.Error().Msgf("Failed to get workspace: %v", err)
+		dto.InternalServerError(ctx)
+		return
+	}
+	if workspace == nil {
+		dto.WorkspaceNotFoundError(ctx)
+		return
+	}
+
+	rawKey, err := generateAPIKey()
+	if err != nil {
+		s.log.Error().Msgf("Failed to generate API key: %v", err)
+		dto.InternalServerError(ctx)
+		return
+	}
+
+	created, err := s.repo.CreateAPIKey(ctx.Request.Context(), repo.APIKeyEntity{
+		WorkspaceID: workspace.ID,
+		Name:        req.Name,
+		Prefix:      rawKey[:len(apiKeyPrefix)+apiKeyShownChars],
+		KeyHash:     hashAPIKey(rawKey),
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		s.log.Error().Msgf("Failed to create API key: %v", err)
+		dto.InternalServerError(ctx)
+		return
+	}
+
+	s.log.Info().Msgf("API key %d (%s) issued for workspace %d", created.ID, created.Name, created.WorkspaceID)
+
+	dto.SuccessCreatedResponse(ctx, IssuedAPIKey{
+		APIKey: toServiceAPIKey(*created),
+		Key:    rawKey,
+	})
+}
+
+func (s *service) ListAPIKeys(ctx *ginext.Context) {
+	keys, err := s.repo.ListAPIKeys(ctx.Request.Context())
+	if err != nil {
+		s.log.Error().Msgf("Failed to list API keys: %v", err)
+		dto.InternalServerError(ctx)
+		return
+	}
+
+	result := make([]APIKey, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, toServiceAPIKey(k))
+	}
+
+	dto.SuccessResponse(ctx, result)
+}
+
+func (s *service) RevokeAPIKey(ctx *ginext.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id < 1 {
+		dto.FieldIncorrectError(ctx, "id")
+		return
+	}
+
+	revoked, err := s.repo.RevokeAPIKey(ctx.Request.Context(), id)
+	if err != nil {
+		s.log.Error().Msgf("Failed to revoke API key: %v", err)
+		dto.InternalServerError(ctx)
+		return
+	}
+	if revoked == nil {
+		dto.APIKeyNotFoundError(ctx)
+		return
+	}
+
+	s.log.Info().Msgf("API key %d (%s) revoked", revoked.ID, revoked.Name)
+
+	dto.SuccessResponse(ctx, toServiceAPIKey(*revoked))
+}
+
+// AuthenticateAPIKey возвращает активный ключ или nil, если ключ неизвестен или отозван
+func (s *service) AuthenticateAPIKey(ctx context.Context, rawKey string) (*APIKey, error) {
+	entity, err := s.repo.UseAPIKey(ctx, hashAPIKey(rawKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check api key: %w", err)
+	}
+	if entity == nil {
+		return nil, nil
+	}
+
+	key := toServiceAPIKey(*entity)
+	return &key, nil
+}
+
+// principal возвращает ключ, которым аутентифицирован запрос. Если его нет,
+// отвечает 401 и возвращает nil — обработчик должен сразу завершиться.
+func principal(ctx *ginext.Context) *APIKey {
+	if v, ok := ctx.Get(APIKeyContextKey); ok {
+		if key, ok := v.(*APIKey); ok && key != nil {
+			return key
+		}
+	}
+	dto.UnauthorizedError(ctx)
+	return nil
+}
+
+func generateAPIKey() (string, error) {
+	b := make([]byte, apiKeyRandomBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(b), nil
+}
+
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/wb-go/wbf/ginext"
+	"secondOne/internal/dto"
+	"secondOne/internal/repo"
+	"secondOne/pkg/validator"
+)
+
+// errBatchRolledBack прерывает транзакцию атомарного пакета на первой ошибке элемента
+var errBatchRolledBack = errors.New("batch rolled back")
+
+func (s *service) CreateUrlBatch(ctx *ginext.Context) {
+	owner := principal(ctx)
+	if owner == nil {
+		return
+	}
+
+	var req struct {
+		Items  []createUrlRequest `json:"items"`
+		Atomic bool               `json:"atomic,omitempty"`
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		s.log.Error().Msgf("Invalid request body: %v", err)
+		dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+		return
+	}
+
+	if len(req.Items) == 0 || len(req.Items) > s.cfg.BatchMaxItems {
+		dto.BadResponseError(ctx, dto.FieldIncorrect, fmt.Sprintf("'items' must contain 1 to %d elements", s.cfg.BatchMaxItems))
+		return
+	}
+
+	result := BatchResult{
+		Atomic: req.Atomic,
+		Items:  make([]BatchItemResult, len(req.Items)),
+	}
+
+	valid := true
+	for i, item := range req.Items {
+		result.Items[i] = BatchItemResult{Index: i}
+		if err := validator.Validate(ctx.Request.Context(), item); err != nil {
+			result.Items[i].fail(&linkError{Status: 400, Code: dto.FieldIncorrect, Desc: err.Error()})
+			valid = false
+		}
+	}
+
+	reqCtx := ctx.Request.Context()
+	if req.Atomic {
+		if !valid {
+			result.rollBack()
+			dto.BatchRejectedError(ctx, result)
+			return
+		}
+
+		err := s.repo.InTx(reqCtx, func(tx repo.Repository) error {
+			for i, item := range req.Items {
+				url, created, err := s.createLink(reqCtx, tx, owner, item)
+				if err != nil {
+					var le *linkError
+					if !errors.As(err, &le) {
+						return err
+					}
+					result.Items[i].fail(le)
+					return errBatchRolledBack
+				}
+				result.Items[i].succeed(url, created)
+			}
+			return nil
+		})
+		if err != nil {
+			if !errors.Is(err, errBatchRolledBack) {
+				s.log.Error().Msgf("Failed to create URL batch: %v", err)
+				dto.InternalServerError(ctx)
+				return
+			}
+			result.rollBack()
+			dto.BatchRejectedError(ctx, result)
+			return
+		}
+	} else {
+		for i, item := range req.Items {
+			if result.Items[i].Status == BatchItemError {
+				continue
+			}
+
+			url, created, err := s.createLink(reqCtx, s.repo, owner, item)
+			if err != nil {
+				var le *linkError
+				if !errors.As(err, &le) {
+					s.log.Error().Msgf("Failed to create URL in batch (item %d): %v", i, err)
+					le = &linkError{Status: 500, Code: dto.ServiceUnavailable, Desc: dto.InternalError}
+				}
+				result.Items[i].fail(le)
+				continue
+			}
+			result.Items[i].succeed(url, created)
+		}
+	}
+
+	for _, item := range result.Items {
+		switch item.Status {
+		case BatchItemCreated:
+			result.Created++
+			s.cacheUrl(reqCtx, *item.Data)
+			s.withShortURL(ctx, item.Data)
+		case BatchItemExisting:
+			s.withShortURL(ctx, item.Data)
+		case BatchItemError:
+			result.Failed++
+		}
+	}
+
+	if result.Created > 0 && result.Failed == 0 {
+		dto.SuccessCreatedResponse(ctx, result)
+		return
+	}
+	dto.SuccessResponse(ctx, result)
+}
+
+func (r *BatchItemResult) succeed(url Url, created bool) {
+	r.Status = BatchItemExisting
+	if created {
+		r.Status = BatchItemCreated
+	}
+	r.Data = &url
+}
+
+func (r *BatchItemResult) fail(err *linkError) {
+	r.Status = BatchItemError
+	r.Data = nil
+	r.Error = &dto.Error{Code: err.Code, Desc: err.Desc}
+}
+
+// rollBack помечает элементы без ошибки как откатившиеся вместе с пакетом
+func (b *BatchResult) rollBack() {
+	b.Created = 0
+	b.Failed = 0
+	for i := range b.Items {
+		if b.Items[i].Status == BatchItemError {
+			b.Failed++
+			continue
+		}
+		b.Items[i].Status = BatchItemRolledBack
+		b.Items[i].Data = nil
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"fmt"
+	"github.com/wb-go/wbf/ginext"
+	"html/template"
+	"net/url"
+	"secondOne/internal/dto"
+	"strings"
+)
+
+// deepLinkFallbackDelay — сколько страница ждёт открытия приложения, прежде чем уйти на запасной адрес, мс
+const deepLinkFallbackDelay = 1500
+
+// DeepLink — адреса мобильного приложения. Посетитель с iOS или Android сначала
+// попадает в приложение, а если оно не установлено — в магазин платформы,
+// без адреса магазина — на обычный адрес назначения.
+type DeepLink struct {
+	IOS          string `json:"ios,omitempty" validate:"omitempty,max=2048"` // например myapp://product/42
+	IOSStore     string `json:"ios_store,omitempty" validate:"omitempty,url"`
+	Android      string `json:"android,omitempty" validate:"omitempty,max=2048"`
+	AndroidStore string `json:"android_store,omitempty" validate:"omitempty,url"`
+}
+
+// схемы, которые выполняют код в браузере вместо открытия приложения
+var forbiddenAppSchemes = map[string]bool{"javascript": true, "data": true, "vbscript": true, "file": true}
+
+// checkDeepLink проверяет, что задан хотя бы один адрес приложения и у адресов есть допустимая схема
+func checkDeepLink(dl *DeepLink) *linkError {
+	if dl == nil {
+		return nil
+	}
+	if dl.IOS == "" && dl.Android == "" {
+		return &linkError{Status: 400, Code: dto.FieldIncorrect, Desc: "Deep link needs an 'ios' or 'android' app URL"}
+	}
+	for field, raw := range map[string]string{"ios": dl.IOS, "android": dl.Android} {
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" || forbiddenAppSchemes[strings.ToLower(u.Scheme)] {
+			return &linkError{Status: 400, Code: dto.FieldIncorrect, Desc: fmt.Sprintf("'%s' must be an app URL like myapp://path", field)}
+		}
+	}
+	return nil
+}
+
+// appTarget возвращает адрес приложения и запасной адрес для устройства посетителя.
+// Пустой адрес приложения — обычный редирект: десктоп, боты, платформы без адреса и ссылки,
+// у которых запасной адрес не http(s): он попадает в скрипт страницы, и javascript: выполнился бы
+// на домене сервиса.
+func appTarget(dl *DeepLink, v visit, web string) (app, fallback string) {
+	if dl == nil {
+		return "", ""
+	}
+	_, os, device := parseUserAgent(v.UA)
+	if device == "Bot" {
+		return "", ""
+	}
+
+	switch osFamily(os) {
+	case "ios":
+		app, fallback = dl.IOS, dl.IOSStore
+	case "android":
+		app, fallback = dl.Android, dl.AndroidStore
+	}
+	if app == "" {
+		return "", ""
+	}
+	if !isWebURL(fallback) {
+		fallback = web
+	}
+	if !isWebURL(fallback) {
+		return "", ""
+	}
+	return app, fallback
+}
+
+// isWebURL сообщает, что адрес — абсолютный http(s)
+func isWebURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	return scheme == "http" || scheme == "https"
+}
+
+var appPage = template.Must(template.New("app").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Открываем приложение</title>
+<style>
+body { font-family: sans-serif; display: flex; justify-content: center; padding-top: 15vh; text-align: center; }
+</style>
+</head>
+<body>
+<main>
+<p>Открываем приложение…</p>
+<p><a href="{{.App}}">Открыть в приложении</a></p>
+<p><a href="{{.Fallback}}" rel="noopener noreferrer">Продолжить без приложения</a></p>
+</main>
+<script>
+(function () {
+  // если приложение открылось, страница уходит в фон и запасной переход отменяется
+  var timer = setTimeout(function () { window.location.replace({{.Fallback}}); }, {{.Delay}});
+  var cancel = function () { if (document.hidden) { clearTimeout(timer); } };
+  document.addEventListener("visibilitychange", cancel);
+  window.addEventListener("pagehide", function () { clearTimeout(timer); });
+  window.location.href = {{.App}};
+})();
+</script>
+</body>
+</html>
+`))
+
+type appPageData struct {
+	App      template.URL // схема приложения проверена в checkDeepLink
+	Fallback string
+	Delay    int
+}
+
+// serveDeepLink отдаёт промежуточную страницу: попытка открыть приложение и запасной переход по таймеру
+func (s *service) serveDeepLink(ctx *ginext.Context, app, fallback string) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Content-Type", "text/html; charset=utf-8")
+	ctx.Status(200)
+	if err := appPage.Execute(ctx.Writer, appPageData{
+		App:      template.URL(app),
+		Fallback: fallback,
+		Delay:    deepLinkFallbackDelay,
+	}); err != nil {
+		s.log.Error().Msgf("Failed to render deep link page: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"net/url"
+	"secondOne/internal/repo"
+)
+
+// buildDestination собирает адрес редиректа из адреса назначения ссылки, её UTM-меток
+// и query-параметров перехода. Приоритет при совпадении ключей:
+//   - UTM-метки ссылки заменяют одноимённые параметры адреса назначения;
+//   - в режиме merge параметры перехода добавляются, только если такого ключа ещё нет;
+//   - в режиме override параметры перехода заменяют и адрес назначения, и UTM-метки.
+//
+// Возвращает итоговый адрес и применённый utm_campaign (пустой, если метки нет).
+func buildDestination(link Url, incoming url.Values) (string, string) {
+	forward := len(incoming) > 0 && link.QueryForwarding != "" && link.QueryForwarding != repo.QueryForwardingOff
+	if link.UTM == nil && !forward {
+		return link.Original, campaignOf(link.Original)
+	}
+
+	dest, err := url.Parse(link.Original)
+	if err != nil {
+		// адрес валидируется при создании, сюда попадают только старые записи
+		return link.Original, ""
+	}
+
+	query := dest.Query()
+	if link.UTM != nil {
+		for key, value := range utmValues(*link.UTM) {
+			query.Set(key, value)
+		}
+	}
+
+	if forward {
+		for key, values := range incoming {
+			if _, exists := query[key]; exists && link.QueryForwarding == repo.QueryForwardingMerge {
+				continue
+			}
+			query[key] = values
+		}
+	}
+
+	dest.RawQuery = query.Encode()
+	return dest.String(), query.Get("utm_campaign")
+}
+
+func utmValues(utm UTM) map[string]string {
+	values := map[string]string{}
+	for key, value := range map[string]string{
+		"utm_source":   utm.Source,
+		"utm_medium":   utm.Medium,
+		"utm_campaign": utm.Campaign,
+		"utm_term":     utm.Term,
+		"utm_content":  utm.Content,
+	} {
+		if value != "" {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+func campaignOf(rawURL string) string {
+	dest, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return dest.Query().Get("utm_campaign")
+}
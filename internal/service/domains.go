@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/ginext"
+	"net"
+	"net/url"
+	"secondOne/internal/dto"
+	"secondOne/pkg/validator"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// domainsRefreshInterval — как часто перечитывать домены: изменения, сделанные
+// через другой экземпляр сервиса, становятся видны не позже этого срока
+const domainsRefreshInterval = 30 * time.Second
+
+// domainSet — снимок зарегистрированных доменов; хост проверяется на каждом переходе,
+// поэтому домены держатся в памяти, а не читаются из БД
+type domainSet struct {
+	byHost   map[string]Domain
+	byID     map[int64]Domain
+	loadedAt time.Time
+}
+
+// loadDomains возвращает снимок доменов, перечитывая его из БД, если он устарел или force.
+// При ошибке БД остаётся прежний снимок, следующая попытка — через domainsRefreshInterval.
+func (s *service) loadDomains(ctx context.Context, force bool) *domainSet {
+	current := s.domains.Load()
+	if current != nil && !force && time.Since(current.loadedAt) < domainsRefreshInterval {
+		return current
+	}
+
+	next := &domainSet{byHost: map[string]Domain{}, byID: map[int64]Domain{}, loadedAt: time.Now()}
+	entities, err := s.repo.ListDomains(ctx)
+	if err != nil {
+		s.log.Warn().Msgf("Failed to load domains: %v", err)
+		if current != nil {
+			next.byHost, next.byID = current.byHost, current.byID
+		}
+	}
+	for _, e := range entities {
+		d := toServiceDomain(e)
+		next.byHost[d.Host] = d
+		next.byID[d.ID] = d
+	}
+
+	s.domains.Store(next)
+	return next
+}
+
+// hostDomainID возвращает id брендированного домена, на который пришёл запрос, или 0 — домен по умолчанию
+func (s *service) hostDomainID(ctx *ginext.Context) int64 {
+	host := ctx.Request.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return s.loadDomains(ctx.Request.Context(), false).byHost[normalizeHost(host)].ID
+}
+
+// linkDomain находит домен для новой ссылки. Только что зарегистрированный домен
+// мог ещё не попасть в снимок, поэтому при промахе домены перечитываются.
+func (s *service) linkDomain(ctx context.Context, owner *APIKey, host string) (*Domain, error) {
+	host = normalizeHost(host)
+	d, ok := s.loadDomains(ctx, false).byHost[host]
+	if !ok {
+		d, ok = s.loadDomains(ctx, true).byHost[host]
+	}
+	// чужой домен неотличим от незарегистрированного
+	if !ok || (d.WorkspaceID != nil && *d.WorkspaceID != owner.WorkspaceID) {
+		return nil, &linkError{Status: 400, Code: dto.DomainNotFound, Desc: "Domain '" + host + "' is not registered"}
+	}
+	return &d, nil
+}
+
+// ownedDomainID — id домена из параметра ?domain= для операций над ссылкой workspace: один и тот же
+// код может принадлежать ссылкам на разных доменах. Без параметра — домен по умолчанию.
+// Если домен не найден, ответ уже отправлен и возвращается false.
+func (s *service) ownedDomainID(ctx *ginext.Context, owner *APIKey) (int64, bool) {
+	host := ctx.Query("domain")
+	if host == "" {
+		return 0, true
+	}
+	d, err := s.linkDomain(ctx.Request.Context(), owner, host)
+	if err != nil {
+		var le *linkError
+		if errors.As(err, &le) {
+			dto.ErrorResponse(ctx, le.Status, le.Code, le.Desc)
+		} else {
+			dto.InternalServerError(ctx)
+		}
+		return 0, false
+	}
+	return d.ID, true
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// domainKey — id домена для запросов к репозиторию, 0 — домен по умолчанию
+func domainKey(id *int64) int64 {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
+
+// linkCode — код в адресе ссылки: алиас короче и понятнее сгенерированного short
+func linkCode(url Url) string {
+	if url.CustomAlias != nil {
+		return *url.CustomAlias
+	}
+	return url.Short
+}
+
+// shortURL собирает полный адрес ссылки: на брендированном домене — https://go.acme.io/code,
+// на домене по умолчанию — адрес сервиса
+func (s *service) shortURL(ctx *ginext.Context, url Url) string {
+	if url.DomainID != nil {
+		if d, ok := s.loadDomains(ctx.Request.Context(), false).byID[*url.DomainID]; ok {
+			return "https://" + d.Host + "/" + linkCode(url)
+		}
+	}
+	return s.shortLink(ctx, linkCode(url))
+}
+
+// shortLink собирает адрес ссылки на домене по умолчанию. Без base_url в конфиге
+// адрес берётся из запроса, с учётом X-Forwarded-Proto за прокси.
+func (s *service) shortLink(ctx *ginext.Context, code string) string {
+	base := strings.TrimRight(s.cfg.BaseURL, "/")
+	if base == "" {
+		scheme := "http"
+		if ctx.Request.TLS != nil || ctx.GetHeader("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		base = scheme + "://" + ctx.Request.Host
+	}
+	return base + "/" + url.PathEscape(code)
+}
+
+// withShortURL дополняет ответ доменом и полным адресом ссылки
+func (s *service) withShortURL(ctx *ginext.Context, url *Url) {
+	url.ShortURL = s.shortURL(ctx, *url)
+	if url.DomainID != nil {
+		if d, ok := s.loadDomains(ctx.Request.Context(), false).byID[*url.DomainID]; ok {
+			url.Domain = d.Host
+		}
+	}
+}
+
+func (s *service) CreateDomain(ctx *ginext.Context) {
+	var req struct {
+		Host        string `json:"host" validate:"required,max=253,fqdn"`
+		WorkspaceID *int64 `json:"workspace_id,omitempty"` // не задан — домен доступен всем workspace
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		s.log.Error().Msgf("Invalid request body: %v", err)
+		dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+		return
+	}
+
+	req.Host = normalizeHost(req.Host)
+	if err := validator.Validate(ctx.Request.Context(), req); err != nil {
+		dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
+		return
+	}
+
+	if req.WorkspaceID != nil {
+		workspace, err := s.repo.GetWorkspace(ctx.Request.Context(), *req.WorkspaceID)
+		if err != nil {
+			s.log.Error().Msgf("Failed to get workspace: %v", err)
+			dto.InternalServerError(ctx)
+			return
+		}
+		if workspace == nil {
+			dto.WorkspaceNotFoundError(ctx)
+			return
+		}
+	}
+
+	domain, err := s.repo.CreateDomain(ctx.Request.Context(), req.Host, req.WorkspaceID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			dto.ErrorResponse(ctx, 409, dto.DomainExists, "Domain is already registered")
+			return
+		}
+		s.log.Error().Msgf("Failed to create domain: %v", err)
+		dto.InternalServerError(ctx)
+		return
+	}
+
+	s.log.Info().Msgf("Domain %d (%s) registered", domain.ID, domain.Host)
+	s.loadDomains(ctx.Request.Context(), true)
+
+	dto.SuccessCreatedResponse(ctx, toServiceDomain(*domain))
+}
+
+func (s *service) ListDomains(ctx *ginext.Context) {
+	domains, err := s.repo.ListDomains(ctx.Request.Context())
+	if err != nil {
+		s.log.Error().Msgf("Failed to list domains: %v", err)
+		dto.InternalServerError(ctx)
+		return
+	}
+
+	result := make([]Domain, 0, len(domains))
+	for _, d := range domains {
+		result = append(result, toServiceDomain(d))
+	}
+
+	dto.SuccessResponse(ctx, result)
+}
+
+func (s *service) DeleteDomain(ctx *ginext.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id < 1 {
+		dto.FieldIncorrectError(ctx, "id")
+		return
+	}
+
+	deleted, err := s.repo.DeleteDomain(ctx.Request.Context(), id)
+	if err != nil {
+		var pgErr *pq.Error
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			dto.ErrorResponse(ctx, 409, dto.DomainInUse, "Domain still has links")
+			return
+		}
+		s.log.Error().Msgf("Failed to delete domain: %v", err)
+		dto.InternalServerError(ctx)
+		return
+	}
+	if deleted == nil {
+		dto.DomainNotFoundError(ctx)
+		return
+	}
+
+	s.log.Info().Msgf("Domain %d (%s) deleted", deleted.ID, deleted.Host)
+	s.loadDomains(ctx.Request.Context(), true)
+
+	dto.SuccessResponse(ctx, toServiceDomain(*deleted))
+}
@@ -1,17 +1,123 @@
 package service
 
 import (
+	"secondOne/internal/dto"
 	"secondOne/internal/repo"
 	"time"
 )
 
 type Url struct {
+	ID                int64        `json:"id"`
+	WorkspaceID       int64        `json:"workspace_id"`
+	Short             string       `json:"short"`
+	Original          string       `json:"original"`
+	CustomAlias       *string      `json:"custom_alias,omitempty"`
+	CreatedAt         time.Time    `json:"created_at"`
+	ExpiresAt         *time.Time   `json:"expires_at,omitempty"`
+	RedirectCode      *int         `json:"redirect_code,omitempty"` // не задан — используется код по умолчанию
+	QueryForwarding   string       `json:"query_forwarding"`
+	UTM               *UTM         `json:"utm,omitempty"`
+	Rules             []TargetRule `json:"rules,omitempty"`    // проверяются по порядку до original
+	Variants          []Variant    `json:"variants,omitempty"` // A/B-ротация, если ни одно правило не сработало
+	PasswordProtected bool         `json:"password_protected"` // хэш пароля наружу и в кэш не попадает
+	PasswordVersion   string       `json:"-"`                  // отпечаток хэша пароля для cookie доступа, см. cachedUrl
+	MaxClicks         *int         `json:"max_clicks,omitempty"`
+	ClicksLeft        *int         `json:"clicks_left,omitempty"` // в кэше может быть устаревшим, лимит проверяется по БД
+	StartsAt          *time.Time   `json:"starts_at,omitempty"`
+	PrelaunchMode     *string      `json:"prelaunch,omitempty"` // не задан — режим по умолчанию
+	PrelaunchURL      *string      `json:"prelaunch_url,omitempty"`
+	ExpiredURL        *string      `json:"expired_url,omitempty"`
+	Title             *string      `json:"title,omitempty"`
+	Interstitial      bool         `json:"interstitial"`
+	DeepLink          *DeepLink    `json:"deep_link,omitempty"`
+	DomainID          *int64       `json:"domain_id,omitempty"` // не задан — домен по умолчанию
+	Domain            string       `json:"domain,omitempty"`
+	ShortURL          string       `json:"short_url,omitempty"` // полный адрес ссылки на её домене
+}
+
+// UTM — метки, которые добавляются к адресу назначения при редиректе
+type UTM struct {
+	Source   string `json:"source,omitempty" validate:"max=100"`
+	Medium   string `json:"medium,omitempty" validate:"max=100"`
+	Campaign string `json:"campaign,omitempty" validate:"max=100"`
+	Term     string `json:"term,omitempty" validate:"max=100"`
+	Content  string `json:"content,omitempty" validate:"max=100"`
+}
+
+type LinkListItem struct {
+	Url
+	TotalClicks int64 `json:"total_clicks"`
+}
+
+type LinkList struct {
+	Items      []LinkListItem `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+type Workspace struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Domain struct {
+	ID          int64     `json:"id"`
+	Host        string    `json:"host"`
+	WorkspaceID *int64    `json:"workspace_id,omitempty"` // не задан — домен доступен всем workspace
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type APIKey struct {
 	ID          int64      `json:"id"`
-	Short       string     `json:"short"`
-	Original    string     `json:"original"`
-	CustomAlias *string    `json:"custom_alias,omitempty"`
+	WorkspaceID int64      `json:"workspace_id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
 	CreatedAt   time.Time  `json:"created_at"`
-	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IssuedAPIKey возвращается один раз при выпуске: сам ключ в БД не хранится
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+const (
+	BatchItemCreated    = "created"
+	BatchItemExisting   = "existing"
+	BatchItemError      = "error"
+	BatchItemRolledBack = "rolled_back"
+)
+
+type BatchItemResult struct {
+	Index  int        `json:"index"`
+	Status string     `json:"status"`
+	Data   *Url       `json:"data,omitempty"`
+	Error  *dto.Error `json:"error,omitempty"`
+}
+
+type BatchResult struct {
+	Atomic  bool              `json:"atomic"`
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Items   []BatchItemResult `json:"items"`
+}
+
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Code  string `json:"code"`
+	Desc  string `json:"desc"`
+	Value string `json:"value,omitempty"`
+}
+
+type ImportResult struct {
+	Format   string           `json:"format"`
+	Rows     int              `json:"rows"`
+	Created  int              `json:"created"`
+	Existing int              `json:"existing"`
+	Failed   int              `json:"failed"`
+	Errors   []ImportRowError `json:"errors,omitempty"` // первые maxImportErrors ошибок
 }
 
 type Click struct {
@@ -49,12 +155,132 @@ type AnalyticsPeriod struct {
 
 func toServiceUrl(e repo.UrlEntity) Url {
 	return Url{
+		ID:                e.ID,
+		WorkspaceID:       e.WorkspaceID,
+		Short:             e.Short,
+		Original:          e.Original,
+		CustomAlias:       e.CustomAlias,
+		CreatedAt:         e.CreatedAt,
+		ExpiresAt:         e.ExpiresAt,
+		RedirectCode:      e.RedirectCode,
+		QueryForwarding:   e.QueryForwarding,
+		UTM:               toServiceUTM(e.UTM),
+		Rules:             toServiceRules(e.Rules),
+		Variants:          toServiceVariants(e.Variants),
+		PasswordProtected: e.PasswordHash != nil,
+		PasswordVersion:   passwordVersion(e.PasswordHash),
+		MaxClicks:         e.MaxClicks,
+		ClicksLeft:        clicksLeft(e),
+		StartsAt:          e.StartsAt,
+		PrelaunchMode:     e.PrelaunchMode,
+		PrelaunchURL:      e.PrelaunchURL,
+		ExpiredURL:        e.ExpiredURL,
+		Title:             e.Title,
+		Interstitial:      e.Interstitial,
+		DeepLink:          toServiceDeepLink(e.DeepLink),
+		DomainID:          e.DomainID,
+	}
+}
+
+func clicksLeft(e repo.UrlEntity) *int {
+	if e.MaxClicks == nil {
+		return nil
+	}
+	left := max(*e.MaxClicks-e.ClickCount, 0)
+	return &left
+}
+
+func toServiceUTM(p *repo.UTMParams) *UTM {
+	if p == nil {
+		return nil
+	}
+	utm := UTM(*p)
+	return &utm
+}
+
+func toServiceRules(rules repo.TargetRules) []TargetRule {
+	if len(rules) == 0 {
+		return nil
+	}
+	result := make([]TargetRule, len(rules))
+	for i, rule := range rules {
+		result[i] = TargetRule(rule)
+	}
+	return result
+}
+
+func toRepoRules(rules []TargetRule) repo.TargetRules {
+	result := make(repo.TargetRules, len(rules))
+	for i, rule := range rules {
+		result[i] = repo.TargetRule(rule)
+	}
+	return result
+}
+
+func toServiceVariants(variants repo.Variants) []Variant {
+	if len(variants) == 0 {
+		return nil
+	}
+	result := make([]Variant, len(variants))
+	for i, v := range variants {
+		result[i] = Variant(v)
+	}
+	return result
+}
+
+func toRepoVariants(variants []Variant) repo.Variants {
+	result := make(repo.Variants, len(variants))
+	for i, v := range variants {
+		result[i] = repo.Variant(v)
+	}
+	return result
+}
+
+func toRepoUTM(u *UTM) *repo.UTMParams {
+	if u == nil {
+		return nil
+	}
+	p := repo.UTMParams(*u)
+	return &p
+}
+
+func toServiceDeepLink(d *repo.DeepLink) *DeepLink {
+	if d == nil {
+		return nil
+	}
+	dl := DeepLink(*d)
+	return &dl
+}
+
+func toRepoDeepLink(d *DeepLink) *repo.DeepLink {
+	if d == nil {
+		return nil
+	}
+	dl := repo.DeepLink(*d)
+	return &dl
+}
+
+func toServiceAPIKey(e repo.APIKeyEntity) APIKey {
+	return APIKey{
 		ID:          e.ID,
-		Short:       e.Short,
-		Original:    e.Original,
-		CustomAlias: e.CustomAlias,
+		WorkspaceID: e.WorkspaceID,
+		Name:        e.Name,
+		Prefix:      e.Prefix,
 		CreatedAt:   e.CreatedAt,
-		ExpiresAt:   e.ExpiresAt,
+		LastUsedAt:  e.LastUsedAt,
+		RevokedAt:   e.RevokedAt,
+	}
+}
+
+func toServiceDomain(e repo.DomainEntity) Domain {
+	return Domain(e)
+}
+
+func toServiceWorkspace(e repo.WorkspaceEntity) Workspace {
+	return Workspace{
+		ID:        e.ID,
+		Name:      e.Name,
+		CreatedAt: e.CreatedAt,
 	}
 }
 
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"github.com/wb-go/wbf/ginext"
+	"html/template"
+	"secondOne/internal/dto"
+)
+
+var unavailablePage = template.Must(template.New("unavailable").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; display: flex; justify-content: center; padding-top: 15vh; text-align: center; }
+</style>
+</head>
+<body>
+<div>
+<h1>{{.Title}}</h1>
+<p>{{.Text}}</p>
+</div>
+</body>
+</html>
+`))
+
+// serveExpired отвечает на переход по истёкшей ссылке: fallback ссылки,
+// затем глобальный shortener.expired_url, иначе 410 SHORT_EXPIRED
+func (s *service) serveExpired(ctx *ginext.Context, url Url) {
+	if url.ExpiredURL != nil {
+		ctx.Redirect(302, *url.ExpiredURL)
+		return
+	}
+	if s.cfg.ExpiredURL != "" {
+		ctx.Redirect(302, s.cfg.ExpiredURL)
+		return
+	}
+	s.unavailable(ctx, 410, dto.ShortExpired, "Short link has expired",
+		"Срок действия ссылки истёк", "Владелец ограничил время, в течение которого работала эта ссылка.")
+}
+
+// serveMissing отвечает на переход по несуществующей или удалённой ссылке:
+// глобальный shortener.not_found_url, иначе 404 SHORT_NOT_FOUND
+func (s *service) serveMissing(ctx *ginext.Context) {
+	if s.cfg.NotFoundURL != "" {
+		ctx.Redirect(302, s.cfg.NotFoundURL)
+		return
+	}
+	s.unavailable(ctx, 404, dto.ShortNotFound, "Short link not found",
+		"Ссылка не найдена", "Проверьте адрес: возможно, в нём опечатка или ссылку удалили.")
+}
+
+// serveExhausted отвечает на переход по ссылке, израсходовавшей max_clicks: 410 LINK_EXHAUSTED
+func (s *service) serveExhausted(ctx *ginext.Context) {
+	s.unavailable(ctx, 410, dto.LinkExhausted, "Short link has reached its click limit",
+		"Ссылка больше не действует", "По этой ссылке уже перешли максимально разрешённое число раз.")
+}
+
+// unavailable отдаёт браузеру HTML-страницу, а API-клиентам — JSON в формате dto
+func (s *service) unavailable(ctx *ginext.Context, status int, code, desc, title, text string) {
+	if !wantsHTML(ctx) {
+		dto.ErrorResponse(ctx, status, code, desc)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/html; charset=utf-8")
+	ctx.Status(status)
+	err := unavailablePage.Execute(ctx.Writer, struct{ Title, Text string }{Title: title, Text: text})
+	if err != nil {
+		s.log.Error().Msgf("Failed to render %d page: %v", status, err)
+	}
+}
+
+// wantsHTML — клиент явно предпочитает HTML (браузер); curl с "*/*" и API-клиенты получают JSON
+func wantsHTML(ctx *ginext.Context) bool {
+	return ctx.NegotiateFormat("application/json", "text/html") == "text/html"
+}
@@ -0,0 +1,433 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/wb-go/wbf/ginext"
+	"secondOne/internal/dto"
+	"secondOne/internal/repo"
+	"secondOne/pkg/validator"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (s *service) UpdateLink(ctx *ginext.Context) {
+	owner := principal(ctx)
+	if owner == nil {
+		return
+	}
+
+	short := ctx.Param("short")
+	if short == "" {
+		dto.FieldIncorrectError(ctx, "short")
+		return
+	}
+
+	var req struct {
+		Original          *string       `json:"original,omitempty" validate:"omitempty,url"`
+		CustomAlias       *string       `json:"custom_alias,omitempty" validate:"omitempty,alphanum,min=3,max=30"`
+		ExpiresAt         *time.Time    `json:"expires_at,omitempty"`
+		ClearExpiresAt    bool          `json:"clear_expires_at,omitempty"`
+		RedirectCode      *int          `json:"redirect_code,omitempty" validate:"omitempty,oneof=301 302 307 308"`
+		ClearRedirectCode bool          `json:"clear_redirect_code,omitempty"`
+		QueryForwarding   *string       `json:"query_forwarding,omitempty" validate:"omitempty,oneof=off merge override"`
+		UTM               *UTM          `json:"utm,omitempty"`
+		ClearUTM          bool          `json:"clear_utm,omitempty"`
+		Rules             *[]TargetRule `json:"rules,omitempty" validate:"omitempty,max=20,dive"`    // [] — удалить правила
+		Variants          *[]Variant    `json:"variants,omitempty" validate:"omitempty,max=10,dive"` // [] — выключить A/B-тест
+		Password          *string       `json:"password,omitempty" validate:"omitempty,min=4,max=72"`
+		ClearPassword     bool          `json:"clear_password,omitempty"`
+		MaxClicks         *int          `json:"max_clicks,omitempty" validate:"omitempty,gte=1"` // учитывает уже израсходованные переходы
+		ClearMaxClicks    bool          `json:"clear_max_clicks,omitempty"`
+		StartsAt          *time.Time    `json:"starts_at,omitempty"`
+		ClearStartsAt     bool          `json:"clear_starts_at,omitempty"`
+		PrelaunchMode     *string       `json:"prelaunch,omitempty" validate:"omitempty,oneof=not_found coming_soon fallback"` // "" — режим по умолчанию
+		PrelaunchURL      *string       `json:"prelaunch_url,omitempty" validate:"omitempty,url"`
+		ClearPrelaunchURL bool          `json:"clear_prelaunch_url,omitempty"`
+		ExpiredURL        *string       `json:"expired_url,omitempty" validate:"omitempty,url"`
+		ClearExpiredURL   bool          `json:"clear_expired_url,omitempty"`
+		Title             *string       `json:"title,omitempty" validate:"omitempty,max=200"` // "" — убрать заголовок
+		Interstitial      *bool         `json:"interstitial,omitempty"`
+		DeepLink          *DeepLink     `json:"deep_link,omitempty"` // заменяет адреса приложения целиком
+		ClearDeepLink     bool          `json:"clear_deep_link,omitempty"`
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		s.log.Error().Msgf("Invalid request body: %v", err)
+		dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+		return
+	}
+
+	if err := validator.Validate(ctx.Request.Context(), req); err != nil {
+		dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
+		return
+	}
+
+	if req.Original == nil && req.CustomAlias == nil &&
+		req.ExpiresAt == nil && !req.ClearExpiresAt &&
+		req.RedirectCode == nil && !req.ClearRedirectCode &&
+		req.QueryForwarding == nil && req.UTM == nil && !req.ClearUTM &&
+		req.Rules == nil && req.Variants == nil &&
+		req.Password == nil && !req.ClearPassword &&
+		req.MaxClicks == nil && !req.ClearMaxClicks &&
+		req.StartsAt == nil && !req.ClearStartsAt &&
+		req.PrelaunchMode == nil && req.PrelaunchURL == nil && !req.ClearPrelaunchURL &&
+		req.ExpiredURL == nil && !req.ClearExpiredURL &&
+		req.Title == nil && req.Interstitial == nil &&
+		req.DeepLink == nil && !req.ClearDeepLink {
+		dto.BadResponseError(ctx, dto.FieldIncorrect, "Nothing to update")
+		return
+	}
+
+	if req.CustomAlias != nil && s.isReserved(*req.CustomAlias) {
+		dto.ErrorResponse(ctx, errAliasReserved.Status, errAliasReserved.Code, errAliasReserved.Desc)
+		return
+	}
+
+	var rules *repo.TargetRules
+	if req.Rules != nil {
+		if le := checkRules(*req.Rules); le != nil {
+			dto.ErrorResponse(ctx, le.Status, le.Code, le.Desc)
+			return
+		}
+		r := toRepoRules(*req.Rules)
+		rules = &r
+	}
+
+	var variants *repo.Variants
+	if req.Variants != nil {
+		if len(*req.Variants) == 1 {
+			dto.BadResponseError(ctx, dto.FieldIncorrect, "A/B test needs at least 2 variants")
+			return
+		}
+		if le := checkVariants(*req.Variants); le != nil {
+			dto.ErrorResponse(ctx, le.Status, le.Code, le.Desc)
+			return
+		}
+		v := toRepoVariants(*req.Variants)
+		variants = &v
+	}
+
+	if !req.ClearDeepLink {
+		if le := checkDeepLink(req.DeepLink); le != nil {
+			dto.ErrorResponse(ctx, le.Status, le.Code, le.Desc)
+			return
+		}
+	}
+
+	// проверяются только новые адреса: сохранённые уже проверены при создании
+	var newRules []TargetRule
+	if req.Rules != nil {
+		newRules = *req.Rules
+	}
+	var newVariants []Variant
+	if req.Variants != nil {
+		newVariants = *req.Variants
+	}
+	var newDeepLink *DeepLink
+	if !req.ClearDeepLink {
+		newDeepLink = req.DeepLink
+	}
+	targets := linkTargets(req.Original, newRules, newVariants, req.PrelaunchURL, req.ExpiredURL, newDeepLink)
+	if le := s.screenTargets(ctx.Request.Context(), targets); le != nil {
+		dto.ErrorResponse(ctx, le.Status, le.Code, le.Desc)
+		return
+	}
+
+	var passwordHash *string
+	if req.Password != nil && !req.ClearPassword {
+		hash, err := hashPassword(*req.Password)
+		if err != nil {
+			s.log.Error().Msgf("Failed to hash link password: %v", err)
+			dto.InternalServerError(ctx)
+			return
+		}
+		passwordHash = &hash
+	}
+
+	domainID, ok := s.ownedDomainID(ctx, owner)
+	if !ok {
+		return
+	}
+	existing, err := s.repo.GetOwnedUrl(ctx.Request.Context(), owner.WorkspaceID, domainID, short, false)
+	if err != nil {
+		s.log.Error().Msgf("failed to get URL: %v", err)
+		dto.InternalServerError(ctx)
+		return
+	}
+	if existing == nil {
+		dto.ShortNotFoundError(ctx)
+		return
+	}
+
+	// новый алиас не должен совпадать с кодом другой ссылки на том же домене, иначе
+	// переходы по чужой ссылке уйдут на эту
+	if req.CustomAlias != nil && *req.CustomAlias != existing.Short &&
+		(existing.CustomAlias == nil || *req.CustomAlias != *existing.CustomAlias) {
+		taken, err := s.repo.AliasTaken(ctx.Request.Context(), domainKey(existing.DomainID), *req.CustomAlias)
+		if err != nil {
+			s.log.Error().Msgf("Failed to check alias: %v", err)
+			dto.InternalServerError(ctx)
+			return
+		}
+		if taken {
+			dto.ErrorResponse(ctx, errAliasTaken.Status, errAliasTaken.Code, errAliasTaken.Desc)
+			return
+		}
+	}
+
+	// окно активности и режим до запуска проверяются с учётом текущих значений ссылки
+	startsAt := pickTime(existing.StartsAt, req.StartsAt, req.ClearStartsAt)
+	expiresAt := pickTime(existing.ExpiresAt, req.ExpiresAt, req.ClearExpiresAt)
+	prelaunchMode := existing.PrelaunchMode
+	if req.PrelaunchMode != nil {
+		prelaunchMode = req.PrelaunchMode
+		if *req.PrelaunchMode == "" {
+			prelaunchMode = nil
+		}
+	}
+	prelaunchURL := existing.PrelaunchURL
+	if req.ClearPrelaunchURL {
+		prelaunchURL = nil
+	} else if req.PrelaunchURL != nil {
+		prelaunchURL = req.PrelaunchURL
+	}
+	if le := checkSchedule(startsAt, expiresAt, prelaunchMode, prelaunchURL); le != nil {
+		dto.ErrorResponse(ctx, le.Status, le.Code, le.Desc)
+		return
+	}
+
+	updated, err := s.repo.UpdateUrl(ctx.Request.Context(), owner.WorkspaceID, existing.ID, repo.UrlUpdate{
+		Original:          req.Original,
+		CustomAlias:       req.CustomAlias,
+		ExpiresAt:         req.ExpiresAt,
+		ClearExpiresAt:    req.ClearExpiresAt,
+		RedirectCode:      req.RedirectCode,
+		ClearRedirectCode: req.ClearRedirectCode,
+		QueryForwarding:   req.QueryForwarding,
+		UTM:               toRepoUTM(req.UTM),
+		ClearUTM:          req.ClearUTM,
+		Rules:             rules,
+		Variants:          variants,
+		PasswordHash:      passwordHash,
+		ClearPassword:     req.ClearPassword,
+		MaxClicks:         req.MaxClicks,
+		ClearMaxClicks:    req.ClearMaxClicks,
+		StartsAt:          req.StartsAt,
+		ClearStartsAt:     req.ClearStartsAt,
+		PrelaunchMode:     req.PrelaunchMode,
+		PrelaunchURL:      req.PrelaunchURL,
+		ClearPrelaunchURL: req.ClearPrelaunchURL,
+		ExpiredURL:        req.ExpiredURL,
+		ClearExpiredURL:   req.ClearExpiredURL,
+		Title:             req.Title,
+		Interstitial:      req.Interstitial,
+		DeepLink:          toRepoDeepLink(req.DeepLink),
+		ClearDeepLink:     req.ClearDeepLink,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			dto.ShortAlreadyExistsError(ctx)
+			return
+		}
+		s.log.Error().Msgf("Failed to update URL: %v", err)
+		dto.InternalServerError(ctx)
+		return
+	}
+	if updated == nil {
+		dto.ShortNotFoundError(ctx)
+		return
+	}
+
+	s.evictUrl(ctx.Request.Context(), existing, updated)
+
+	url := toServiceUrl(*updated)
+	s.withShortURL(ctx, &url)
+	dto.SuccessResponse(ctx, url)
+}
+
+// pickTime возвращает значение поля после частичного обновления
+func pickTime(current, next *time.Time, clear bool) *time.Time {
+	if clear {
+		return nil
+	}
+	if next != nil {
+		return next
+	}
+	return current
+}
+
+func (s *service) DeleteLink(ctx *ginext.Context) {
+	owner := principal(ctx)
+	if owner == nil {
+		return
+	}
+
+	short := ctx.Param("short")
+	if short == "" {
+		dto.FieldIncorrectError(ctx, "short")
+		return
+	}
+
+	domainID, ok := s.ownedDomainID(ctx, owner)
+	if !ok {
+		return
+	}
+
+	deleted, err := s.repo.DeleteUrl(ctx.Request.Context(), owner.WorkspaceID, domainID, short)
+	if err != nil {
+		s.log.Error().Msgf("Failed to delete URL: %v", err)
+		dto.InternalServerError(ctx)
+		return
+	}
+	if len(deleted) == 0 {
+		dto.ShortNotFoundError(ctx)
+		return
+	}
+
+	// код мог совпасть у нескольких строк, созданных до проверки уникальности, — из кэша убираются все
+	for i := range deleted {
+		s.evictUrl(ctx.Request.Context(), &deleted[i])
+	}
+
+	dto.SuccessResponse(ctx, toServiceUrl(deleted[0]))
+}
+
+// evictUrl удаляет из Redis все ключи, по которым ссылка могла быть закэширована
+func (s *service) evictUrl(ctx context.Context, urls ...*repo.UrlEntity) {
+	if s.rdb == nil {
+		return
+	}
+
+	var keys []string
+	for _, u := range urls {
+		if u == nil {
+			continue
+		}
+		keys = append(keys, urlCacheKeys(u.DomainID, u.Short, u.CustomAlias)...)
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	if err := s.rdb.Del(ctx, keys...).Err(); err != nil {
+		s.log.Warn().Msgf("Failed to evict URL from Redis: %v", err)
+	}
+}
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+func (s *service) ListLinks(ctx *ginext.Context) {
+	owner := principal(ctx)
+	if owner == nil {
+		return
+	}
+
+	filter := repo.UrlListFilter{
+		WorkspaceID: owner.WorkspaceID,
+		Limit:       defaultListLimit,
+		Search:      strings.TrimSpace(ctx.Query("q")),
+	}
+
+	if v := ctx.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			dto.BadResponseError(ctx, dto.FieldIncorrect, fmt.Sprintf("'limit' must be between 1 and %d", maxListLimit))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if v := ctx.Query("cursor"); v != "" {
+		afterID, err := decodeCursor(v)
+		if err != nil {
+			dto.FieldBadFormatError(ctx, "cursor")
+			return
+		}
+		filter.AfterID = afterID
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseTimeQuery(ctx.Query("created_from")); err != nil {
+		dto.BadResponseError(ctx, dto.FieldBadFormat, "invalid 'created_from', must be RFC3339 or YYYY-MM-DD")
+		return
+	}
+	if filter.CreatedTo, err = parseTimeQuery(ctx.Query("created_to")); err != nil {
+		dto.BadResponseError(ctx, dto.FieldBadFormat, "invalid 'created_to', must be RFC3339 or YYYY-MM-DD")
+		return
+	}
+	if filter.CreatedTo != nil && len(ctx.Query("created_to")) == len("2006-01-02") {
+		// дата без времени включает весь день
+		end := filter.CreatedTo.Add(24 * time.Hour)
+		filter.CreatedTo = &end
+	}
+
+	if v := ctx.Query("expired"); v != "" {
+		expired, err := strconv.ParseBool(v)
+		if err != nil {
+			dto.FieldBadFormatError(ctx, "expired")
+			return
+		}
+		filter.Expired = &expired
+	}
+
+	// запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	limit := filter.Limit
+	filter.Limit++
+
+	rows, err := s.repo.ListUrls(ctx.Request.Context(), filter)
+	if err != nil {
+		s.log.Error().Msgf("Failed to list URLs: %v", err)
+		dto.InternalServerError(ctx)
+		return
+	}
+
+	result := LinkList{Items: make([]LinkListItem, 0, len(rows))}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		result.NextCursor = encodeCursor(rows[len(rows)-1].ID)
+	}
+	for _, row := range rows {
+		item := LinkListItem{Url: toServiceUrl(row.UrlEntity), TotalClicks: row.TotalClicks}
+		s.withShortURL(ctx, &item.Url)
+		result.Items = append(result.Items, item)
+	}
+
+	dto.SuccessResponse(ctx, result)
+}
+
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return id, nil
+}
+
+// parseTimeQuery принимает RFC3339 или дату YYYY-MM-DD; пустая строка — фильтр не задан
+func parseTimeQuery(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"github.com/wb-go/wbf/ginext"
+	"golang.org/x/crypto/bcrypt"
+	"html/template"
+	"net/http"
+	"secondOne/internal/dto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// passwordCookiePrefix + short — cookie с подписанным сроком доступа к защищённой ссылке
+const passwordCookiePrefix = "pw_"
+
+var passwordForm = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Ссылка защищена паролем</title>
+<style>
+body { font-family: sans-serif; display: flex; justify-content: center; padding-top: 15vh; }
+form { display: flex; flex-direction: column; gap: 12px; width: 280px; }
+.error { color: #c0392b; margin: 0; }
+</style>
+</head>
+<body>
+<form method="post">
+<h1>Ссылка защищена паролем</h1>
+{{if .}}<p class="error">{{.}}</p>{{end}}
+<input type="password" name="password" placeholder="Пароль" autofocus required>
+<button type="submit">Открыть</button>
+</form>
+</body>
+</html>
+`))
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// UnlockLink принимает пароль из формы. Неудачные попытки считаются по IP в Redis;
+// при успехе ставится подписанная cookie и посетитель возвращается на GET той же ссылки.
+func (s *service) UnlockLink(ctx *ginext.Context) {
+	// форма могла прийти со страницы предпросмотра /<code>+: после входа посетитель возвращается туда же
+	short := strings.TrimSuffix(ctx.Param("short_url"), "+")
+	if short == "" {
+		dto.FieldIncorrectError(ctx, "short_url")
+		return
+	}
+
+	entity, err := s.repo.GetUrlByShort(ctx.Request.Context(), s.hostDomainID(ctx), short)
+	if err != nil {
+		s.log.Error().Msgf("failed to get URL: %v", err)
+		dto.InternalServerError(ctx)
+		return
+	}
+	if entity == nil {
+		s.serveMissing(ctx)
+		return
+	}
+	if entity.PasswordHash == nil {
+		ctx.Redirect(303, ctx.Request.URL.RequestURI())
+		return
+	}
+
+	attemptsKey := fmt.Sprintf("pw_attempts:%s", ctx.ClientIP())
+	if s.rdb != nil {
+		attempts, err := s.rdb.Client.Get(ctx.Request.Context(), attemptsKey).Int()
+		if err == nil && attempts >= s.cfg.PasswordMaxAttempts {
+			s.renderPasswordForm(ctx, 429, "Слишком много попыток, попробуйте позже")
+			return
+		}
+	}
+
+	password := ctx.PostForm("password")
+	if bcrypt.CompareHashAndPassword([]byte(*entity.PasswordHash), []byte(password)) != nil {
+		s.log.Warn().Msgf("Wrong password for short=%s from %s", entity.Short, ctx.ClientIP())
+		if s.rdb != nil {
+			pipe := s.rdb.Client.TxPipeline()
+			pipe.Incr(ctx.Request.Context(), attemptsKey)
+			// окно отсчитывается от первой неудачной попытки
+			pipe.ExpireNX(ctx.Request.Context(), attemptsKey, s.cfg.PasswordAttemptWindow)
+			if _, err := pipe.Exec(ctx.Request.Context()); err != nil {
+				s.log.Warn().Msgf("Failed to count password attempt: %v", err)
+			}
+		}
+		s.renderPasswordForm(ctx, 401, "Неверный пароль")
+		return
+	}
+
+	expires := time.Now().Add(s.cfg.PasswordCookieTTL)
+	ctx.SetSameSite(http.SameSiteLaxMode) // cookie уходит и при переходе по ссылке с другого сайта
+	ctx.SetCookie(passwordCookiePrefix+entity.Short, s.signLinkAccess(entity.Short, passwordVersion(entity.PasswordHash), expires),
+		int(s.cfg.PasswordCookieTTL.Seconds()), "/", "", ctx.Request.TLS != nil, true)
+
+	// 303: повторный запрос уйдёт GET-ом и пройдёт обычный редирект с учётом клика
+	ctx.Redirect(303, ctx.Request.URL.RequestURI())
+}
+
+// hasLinkAccess проверяет cookie, выданную UnlockLink для этой ссылки и её текущего пароля
+func (s *service) hasLinkAccess(ctx *ginext.Context, short, version string) bool {
+	value, err := ctx.Cookie(passwordCookiePrefix + short)
+	if err != nil {
+		return false
+	}
+
+	expiresRaw, _, ok := strings.Cut(value, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(expiresRaw, 10, 64)
+	if err != nil {
+		return false
+	}
+	expires := time.Unix(unix, 0)
+	if time.Now().After(expires) {
+		return false
+	}
+
+	return hmac.Equal([]byte(value), []byte(s.signLinkAccess(short, version, expires)))
+}
+
+// signLinkAccess возвращает значение cookie "<unix-время истечения>.<HMAC>". В подпись входит
+// версия пароля: после смены или снятия пароля выданные cookie перестают подходить.
+func (s *service) signLinkAccess(short, version string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	mac := hmac.New(sha256.New, s.cfg.PasswordSecret)
+	mac.Write([]byte(short + "|" + version + "|" + exp))
+	return exp + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// passwordVersion — отпечаток хэша пароля: bcrypt солит каждый хэш, поэтому
+// новый пароль, даже совпадающий со старым, даёт новую версию
+func passwordVersion(hash *string) string {
+	if hash == nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(*hash))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+func (s *service) renderPasswordForm(ctx *ginext.Context, status int, message string) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Content-Type", "text/html; charset=utf-8")
+	ctx.Status(status)
+	if err := passwordForm.Execute(ctx.Writer, message); err != nil {
+		s.log.Error().Msgf("Failed to render password form: %v", err)
+	}
+}
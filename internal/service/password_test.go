@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"secondOne/internal/repo"
+	"strings"
+	"testing"
+)
+
+// fakeRepo хранит ссылки в памяти; остальные методы репозитория в тестах не вызываются
+type fakeRepo struct {
+	repo.Repository
+	urls    []repo.UrlEntity
+	queried []string
+}
+
+func (r *fakeRepo) GetUrlByShort(_ context.Context, domainID int64, short string) (*repo.UrlEntity, error) {
+	r.queried = append(r.queried, short)
+	for i, u := range r.urls {
+		if domainKey(u.DomainID) == domainID && (u.Short == short || derefString(u.CustomAlias) == short) {
+			return &r.urls[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeRepo) AliasTaken(_ context.Context, domainID int64, alias string) (bool, error) {
+	for _, u := range r.urls {
+		if domainKey(u.DomainID) == domainID && (u.Short == alias || derefString(u.CustomAlias) == alias) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CreateUrl повторяет проверки БД: short уникален везде, код — в пределах домена
+func (r *fakeRepo) CreateUrl(ctx context.Context, url repo.UrlEntity) (int64, error) {
+	for _, code := range []string{url.Short, derefString(url.CustomAlias)} {
+		taken, _ := r.AliasTaken(ctx, domainKey(url.DomainID), code)
+		if code != "" && taken {
+			return 0, repo.ErrUrlConflict
+		}
+	}
+	for _, u := range r.urls {
+		if u.Short == url.Short {
+			return 0, repo.ErrUrlConflict
+		}
+	}
+	url.ID = int64(len(r.urls) + 1)
+	r.urls = append(r.urls, url)
+	return url.ID, nil
+}
+
+func (r *fakeRepo) StreamUrls(_ context.Context, workspaceID int64, _ bool, fn func(repo.UrlWithClicks) error) error {
+	for _, u := range r.urls {
+		if u.WorkspaceID == workspaceID && u.DeletedAt == nil {
+			if err := fn(repo.UrlWithClicks{UrlEntity: u}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepo) ListDomains(context.Context) ([]repo.DomainEntity, error) {
+	return nil, nil
+}
+
+func newPasswordService(t *testing.T, password string) (*service, *fakeRepo) {
+	t.Helper()
+	hash, err := hashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &fakeRepo{urls: []repo.UrlEntity{{Short: "abc", Original: "https://example.com", PasswordHash: &hash}}}
+	log := zerolog.Nop()
+	s := NewService(r, &log, nil, nil, nil, nil, Config{PasswordSecret: []byte("secret")}).(*service)
+	return s, r
+}
+
+func unlock(s *service, path, password string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	form := url.Values{"password": {password}}
+	ctx.Request = httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	ctx.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	ctx.Params = gin.Params{{Key: "short_url", Value: strings.TrimPrefix(path, "/")}}
+	s.UnlockLink(ctx)
+	ctx.Writer.WriteHeaderNow() // как gin после обработчика: у ответа на POST может не быть тела
+	return w
+}
+
+func TestUnlockLink(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"link", "/abc"},
+		{"preview", "/abc+"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, r := newPasswordService(t, "open sesame")
+
+			w := unlock(s, tt.path, "open sesame")
+			if w.Code != http.StatusSeeOther {
+				t.Fatalf("status = %d, want 303", w.Code)
+			}
+			if got := w.Header().Get("Location"); got != tt.path {
+				t.Fatalf("Location = %q, want %q", got, tt.path)
+			}
+			if len(r.queried) != 1 || r.queried[0] != "abc" {
+				t.Fatalf("link looked up by %v, want [abc]", r.queried)
+			}
+			cookies := w.Result().Cookies()
+			if len(cookies) != 1 || cookies[0].Name != passwordCookiePrefix+"abc" {
+				t.Fatalf("access cookie is not set: %v", cookies)
+			}
+		})
+	}
+}
+
+func TestUnlockLinkWrongPassword(t *testing.T) {
+	s, _ := newPasswordService(t, "open sesame")
+
+	w := unlock(s, "/abc+", "guess")
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", w.Code)
+	}
+	if cookies := w.Result().Cookies(); len(cookies) != 0 {
+		t.Fatalf("cookie set for a wrong password: %v", cookies)
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"github.com/wb-go/wbf/ginext"
+	"html/template"
+	"secondOne/internal/dto"
+	"strings"
+	"time"
+)
+
+// LinkPreview — ответ предпросмотра для API-клиентов
+type LinkPreview struct {
+	Short        string    `json:"short"`
+	Original     string    `json:"original"`
+	Title        string    `json:"title,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	Interstitial bool      `json:"interstitial"`
+	Varies       bool      `json:"varies"` // адрес зависит от устройства, страны или A/B-теста
+}
+
+var leavePage = template.Must(template.New("leave").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<meta name="referrer" content="no-referrer">
+<title>{{if .Title}}{{.Title}}{{else}}Переход по ссылке{{end}}</title>
+<style>
+body { font-family: sans-serif; display: flex; justify-content: center; padding-top: 15vh; }
+main { max-width: 560px; }
+.url { word-break: break-all; background: #f4f4f4; padding: 8px; }
+.muted { color: #777; }
+</style>
+</head>
+<body>
+<main>
+{{if .Preview}}<h1>Предпросмотр ссылки</h1>{{else}}<h1>Вы покидаете сайт</h1>
+<p>Ссылка ведёт на внешний ресурс. Убедитесь, что доверяете ему.</p>{{end}}
+{{if .Title}}<p><b>{{.Title}}</b></p>{{end}}
+<p class="url">{{.Destination}}</p>
+{{if .Varies}}<p class="muted">Адрес может отличаться в зависимости от устройства и страны.</p>{{end}}
+<p class="muted">Ссылка создана {{.CreatedAt.Format "02.01.2006"}}</p>
+<p><a href="{{.Continue}}" rel="noopener noreferrer">Перейти</a></p>
+</main>
+</body>
+</html>
+`))
+
+type leavePageData struct {
+	Preview     bool
+	Title       string
+	Destination string
+	Varies      bool
+	CreatedAt   time.Time
+	Continue    string
+}
+
+// servePreview показывает, куда ведёт ссылка, не совершая перехода:
+// клик не записывается и max_clicks не расходуется
+func (s *service) servePreview(ctx *ginext.Context, url Url) {
+	title := ""
+	if url.Title != nil {
+		title = *url.Title
+	}
+	varies := len(url.Rules) > 0 || len(url.Variants) > 0 || url.DeepLink != nil
+
+	if !wantsHTML(ctx) {
+		dto.SuccessResponse(ctx, LinkPreview{
+			Short:        url.Short,
+			Original:     url.Original,
+			Title:        title,
+			CreatedAt:    url.CreatedAt,
+			Interstitial: url.Interstitial,
+			Varies:       varies,
+		})
+		return
+	}
+
+	// «Перейти» ведёт на ту же короткую ссылку без "+", и переход проходит обычным путём
+	next := *ctx.Request.URL
+	next.Path = strings.TrimSuffix(next.Path, "+")
+	next.RawPath = ""
+
+	s.renderLeavePage(ctx, leavePageData{
+		Preview:     true,
+		Title:       title,
+		Destination: url.Original,
+		Varies:      varies,
+		CreatedAt:   url.CreatedAt,
+		Continue:    next.RequestURI(),
+	})
+}
+
+// serveInterstitial показывает предупреждение вместо редиректа; переход уже учтён
+func (s *service) serveInterstitial(ctx *ginext.Context, url Url, destination string) {
+	title := ""
+	if url.Title != nil {
+		title = *url.Title
+	}
+	s.renderLeavePage(ctx, leavePageData{
+		Title:       title,
+		Destination: destination,
+		CreatedAt:   url.CreatedAt,
+		Continue:    destination,
+	})
+}
+
+func (s *service) renderLeavePage(ctx *ginext.Context, data leavePageData) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Content-Type", "text/html; charset=utf-8")
+	ctx.Status(200)
+	if err := leavePage.Execute(ctx.Writer, data); err != nil {
+		s.log.Error().Msgf("Failed to render leave page: %v", err)
+	}
+}
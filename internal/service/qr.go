@@ -0,0 +1,172 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/wb-go/wbf/ginext"
+	"secondOne/internal/dto"
+	"secondOne/internal/repo"
+	"secondOne/pkg/qr"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultQRSize   = 256
+	minQRSize       = 64
+	maxQRSize       = 2048
+	defaultQRMargin = 4
+	maxQRMargin     = 16
+)
+
+// qrParams — параметры GET /v1/links/:short/qr
+type qrParams struct {
+	Format string // png или svg
+	Size   int
+	Margin int
+	Level  string
+	FG     string
+	BG     string
+}
+
+// QRCode отдаёт QR-код короткой ссылки в PNG или SVG. В код зашита ссылка с меткой
+// ?src=qr, так что сканирования видны в аналитике отдельно от обычных переходов.
+// Готовые изображения кэшируются в Redis: содержимое зависит только от адреса и параметров.
+func (s *service) QRCode(ctx *ginext.Context) {
+	owner := principal(ctx)
+	if owner == nil {
+		return
+	}
+
+	short := ctx.Param("short")
+	domainID, ok := s.ownedDomainID(ctx, owner)
+	if !ok {
+		return
+	}
+	entity, err := s.repo.GetOwnedUrl(ctx.Request.Context(), owner.WorkspaceID, domainID, short, false)
+	if err != nil {
+		s.log.Error().Msgf("Failed to get URL for QR code: %v", err)
+		dto.InternalServerError(ctx)
+		return
+	}
+	if entity == nil {
+		dto.ShortNotFoundError(ctx)
+		return
+	}
+
+	params, opt, err := parseQRParams(ctx)
+	if err != nil {
+		dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
+		return
+	}
+
+	content := s.shortURL(ctx, toServiceUrl(*entity)) + "?src=" + repo.ClickSourceQR
+	contentType := "image/png"
+	if params.Format == "svg" {
+		contentType = "image/svg+xml"
+	}
+
+	key := qrCacheKey(content, params)
+	data, cached := s.cachedQR(ctx, key)
+	if !cached {
+		if params.Format == "svg" {
+			data, err = qr.SVG(content, opt)
+		} else {
+			data, err = qr.PNG(content, opt)
+		}
+		if errors.Is(err, qr.ErrTooSmall) {
+			dto.BadResponseError(ctx, dto.FieldIncorrect, "'size' is too small for this link, increase it or reduce 'margin'")
+			return
+		}
+		if err != nil {
+			s.log.Error().Msgf("Failed to render QR code for short=%s: %v", entity.Short, err)
+			dto.InternalServerError(ctx)
+			return
+		}
+		s.cacheQR(ctx, key, data)
+	}
+
+	ctx.Header("Cache-Control", "private, max-age=3600")
+	ctx.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.%s"`, entity.Short, params.Format))
+	ctx.Data(200, contentType, data)
+}
+
+// parseQRParams читает параметры из query; незаданные берутся по умолчанию
+func parseQRParams(ctx *ginext.Context) (qrParams, qr.Options, error) {
+	params := qrParams{
+		Format: strings.ToLower(ctx.DefaultQuery("format", "png")),
+		Size:   defaultQRSize,
+		Margin: defaultQRMargin,
+		Level:  strings.ToUpper(ctx.DefaultQuery("level", "M")),
+		FG:     strings.ToLower(strings.TrimPrefix(ctx.DefaultQuery("fg", "000000"), "#")),
+		BG:     strings.ToLower(strings.TrimPrefix(ctx.DefaultQuery("bg", "ffffff"), "#")),
+	}
+
+	if params.Format != "png" && params.Format != "svg" {
+		return params, qr.Options{}, errors.New("'format' must be png or svg")
+	}
+	if v := ctx.Query("size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < minQRSize || size > maxQRSize {
+			return params, qr.Options{}, fmt.Errorf("'size' must be between %d and %d", minQRSize, maxQRSize)
+		}
+		params.Size = size
+	}
+	if v := ctx.Query("margin"); v != "" {
+		margin, err := strconv.Atoi(v)
+		if err != nil || margin < 0 || margin > maxQRMargin {
+			return params, qr.Options{}, fmt.Errorf("'margin' must be between 0 and %d", maxQRMargin)
+		}
+		params.Margin = margin
+	}
+	if !qr.ValidLevel(params.Level) {
+		return params, qr.Options{}, errors.New("'level' must be one of L, M, Q, H")
+	}
+
+	fg, err := qr.ParseColor(params.FG)
+	if err != nil {
+		return params, qr.Options{}, errors.New("'fg' must be a hex color: RGB, RRGGBB or RRGGBBAA")
+	}
+	bg, err := qr.ParseColor(params.BG)
+	if err != nil {
+		return params, qr.Options{}, errors.New("'bg' must be a hex color: RGB, RRGGBB or RRGGBBAA")
+	}
+	if fg == bg {
+		return params, qr.Options{}, errors.New("'fg' and 'bg' must differ, otherwise the code cannot be scanned")
+	}
+
+	return params, qr.Options{
+		Size:       params.Size,
+		Margin:     params.Margin,
+		Level:      params.Level,
+		Foreground: fg,
+		Background: bg,
+	}, nil
+}
+
+func qrCacheKey(content string, p qrParams) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%d|%s|%s|%s", content, p.Format, p.Size, p.Margin, p.Level, p.FG, p.BG)))
+	return "qr:" + hex.EncodeToString(sum[:16])
+}
+
+func (s *service) cachedQR(ctx *ginext.Context, key string) ([]byte, bool) {
+	if s.rdb == nil {
+		return nil, false
+	}
+	data, err := s.rdb.Client.Get(ctx.Request.Context(), key).Bytes()
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+func (s *service) cacheQR(ctx *ginext.Context, key string, data []byte) {
+	if s.rdb == nil {
+		return
+	}
+	if err := s.rdb.Client.Set(ctx.Request.Context(), key, data, s.cfg.QRCacheTTL).Err(); err != nil {
+		s.log.Warn().Msgf("Failed to cache QR code in Redis: %v", err)
+	}
+}
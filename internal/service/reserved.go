@@ -0,0 +1,46 @@
+package service
+
+import (
+	"github.com/wb-go/wbf/ginext"
+	"net/http"
+	"strings"
+)
+
+// defaultReservedWords — пути корня, которые заняты сервисом или могут понадобиться ему.
+// Дополняются списком shortener.reserved_words из конфига.
+var defaultReservedWords = []string{
+	"v1", "v2", "v3", "api", "admin", "healthz", "health", "readyz", "metrics", "status",
+	"static", "assets", "docs", "swagger", "login", "logout", "signup", "auth", "oauth",
+	"dashboard", "app", "www", "s", "qr", "robots", "favicon", "sitemap",
+}
+
+func reservedSet(extra []string) map[string]bool {
+	reserved := make(map[string]bool, len(defaultReservedWords)+len(extra))
+	for _, word := range append(defaultReservedWords, extra...) {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			reserved[word] = true
+		}
+	}
+	return reserved
+}
+
+// isReserved сообщает, что код совпадает с зарезервированным словом без учёта регистра
+func (s *service) isReserved(code string) bool {
+	return s.reserved[strings.ToLower(code)]
+}
+
+// RootLink обслуживает короткие ссылки в корне хоста: GET https://host/abc — переход,
+// POST — форма пароля. Зарезервированные слова в корне не разрешаются, даже если такой
+// алиас появился до их резервирования: ссылка остаётся доступной по /v1/s/.
+func (s *service) RootLink(ctx *ginext.Context) {
+	if s.isReserved(strings.TrimSuffix(ctx.Param("short_url"), "+")) {
+		s.serveMissing(ctx)
+		return
+	}
+
+	if ctx.Request.Method == http.MethodPost {
+		s.UnlockLink(ctx)
+		return
+	}
+	s.Redirect(ctx)
+}
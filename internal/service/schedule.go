@@ -0,0 +1,81 @@
+package service
+
+import (
+	"github.com/wb-go/wbf/ginext"
+	"html/template"
+	"secondOne/internal/dto"
+	"secondOne/internal/repo"
+	"time"
+)
+
+const defaultPrelaunchMode = repo.PrelaunchNotFound
+
+var comingSoonPage = template.Must(template.New("coming_soon").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Скоро</title>
+<style>
+body { font-family: sans-serif; display: flex; justify-content: center; padding-top: 15vh; text-align: center; }
+</style>
+</head>
+<body>
+<div>
+<h1>Скоро здесь что-то будет</h1>
+<p>Ссылка заработает {{.}}</p>
+</div>
+</body>
+</html>
+`))
+
+// notStarted сообщает, что ссылка создана заранее и её время ещё не пришло
+func notStarted(url Url) bool {
+	return url.StartsAt != nil && url.StartsAt.After(time.Now())
+}
+
+// servePrelaunch отвечает на переход до starts_at по режиму ссылки или режиму по умолчанию.
+// Переход не записывается в клики и не расходует max_clicks.
+func (s *service) servePrelaunch(ctx *ginext.Context, url Url) {
+	mode := s.cfg.PrelaunchMode
+	if url.PrelaunchMode != nil {
+		mode = *url.PrelaunchMode
+	}
+	ctx.Header("Cache-Control", "no-store")
+
+	switch mode {
+	case repo.PrelaunchFallback:
+		if url.PrelaunchURL != nil {
+			ctx.Redirect(302, *url.PrelaunchURL)
+			return
+		}
+		// без адреса fallback ведёт себя как not_found
+		s.serveMissing(ctx)
+
+	case repo.PrelaunchComingSoon:
+		if s.cfg.ComingSoonURL != "" {
+			ctx.Redirect(302, s.cfg.ComingSoonURL)
+			return
+		}
+		ctx.Header("Content-Type", "text/html; charset=utf-8")
+		ctx.Status(200)
+		if err := comingSoonPage.Execute(ctx.Writer, url.StartsAt.UTC().Format("02.01.2006 в 15:04 UTC")); err != nil {
+			s.log.Error().Msgf("Failed to render coming soon page: %v", err)
+		}
+
+	default:
+		s.serveMissing(ctx)
+	}
+}
+
+// checkSchedule проверяет согласованность окна активности и режима до запуска
+func checkSchedule(startsAt, expiresAt *time.Time, mode, fallbackURL *string) *linkError {
+	if startsAt != nil && expiresAt != nil && !startsAt.Before(*expiresAt) {
+		return &linkError{Status: 400, Code: dto.FieldIncorrect, Desc: "'starts_at' must be before 'expires_at'"}
+	}
+	if mode != nil && *mode == repo.PrelaunchFallback && fallbackURL == nil {
+		return &linkError{Status: 400, Code: dto.FieldIncorrect, Desc: "'prelaunch_url' is required for prelaunch 'fallback'"}
+	}
+	return nil
+}
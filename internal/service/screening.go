@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"secondOne/internal/dto"
+	"secondOne/pkg/screening"
+)
+
+// URLScreener проверяет адрес назначения перед сохранением ссылки
+type URLScreener interface {
+	Screen(ctx context.Context, rawURL string) *screening.Rejection
+}
+
+// linkTargets собирает адреса, на которые ссылка может отправить посетителя.
+// Адреса приложений в deep link не проверяются: у них своя схема, их проверяет checkDeepLink.
+func linkTargets(original *string, rules []TargetRule, variants []Variant, prelaunchURL, expiredURL *string, dl *DeepLink) []string {
+	var targets []string
+	if original != nil {
+		targets = append(targets, *original)
+	}
+	for _, rule := range rules {
+		targets = append(targets, rule.URL)
+	}
+	for _, variant := range variants {
+		targets = append(targets, variant.URL)
+	}
+	if prelaunchURL != nil {
+		targets = append(targets, *prelaunchURL)
+	}
+	if expiredURL != nil {
+		targets = append(targets, *expiredURL)
+	}
+	if dl != nil {
+		targets = append(targets, dl.IOSStore, dl.AndroidStore)
+	}
+	return targets
+}
+
+// screenTargets отклоняет ссылку, если хотя бы один из адресов не прошёл проверку
+func (s *service) screenTargets(ctx context.Context, targets []string) *linkError {
+	if s.screener == nil {
+		return nil
+	}
+	for _, target := range targets {
+		if target == "" {
+			continue
+		}
+		if rej := s.screener.Screen(ctx, target); rej != nil {
+			s.log.Warn().Msgf("Destination URL rejected (%s): %s", rej.Reason, target)
+			return &linkError{Status: 400, Code: dto.UrlRejected, Desc: "Destination URL is not allowed: " + rej.Detail}
+		}
+	}
+	return nil
+}
@@ -10,41 +10,151 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/wb-go/wbf/ginext"
 	"github.com/wb-go/wbf/redis"
-	"math/rand"
 	"secondOne/internal/dto"
 	"secondOne/internal/repo"
+	"secondOne/pkg/geoip"
+	"secondOne/pkg/shortcode"
 	"secondOne/pkg/validator"
+	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 )
 
 type Service interface {
 	CreateUrl(ctx *ginext.Context)
+	CreateUrlBatch(ctx *ginext.Context)
 	Redirect(ctx *ginext.Context)
-	recordClick(ctx context.Context, short, ip, ua, referer string)
+	UnlockLink(ctx *ginext.Context)
+	recordClick(ctx context.Context, short string, v visit)
 	ShowAnalytics(ctx *ginext.Context)
+	UpdateLink(ctx *ginext.Context)
+	DeleteLink(ctx *ginext.Context)
+	ListLinks(ctx *ginext.Context)
+	ImportLinks(ctx *ginext.Context)
+	ExportLinks(ctx *ginext.Context)
+	QRCode(ctx *ginext.Context)
+	CreateAPIKey(ctx *ginext.Context)
+	ListAPIKeys(ctx *ginext.Context)
+	RevokeAPIKey(ctx *ginext.Context)
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (*APIKey, error)
+	CreateWorkspace(ctx *ginext.Context)
+	ListWorkspaces(ctx *ginext.Context)
+	CreateDomain(ctx *ginext.Context)
+	ListDomains(ctx *ginext.Context)
+	DeleteDomain(ctx *ginext.Context)
+	RootLink(ctx *ginext.Context)
+}
+
+// Config — настройки поведения сервиса
+type Config struct {
+	ShortCodeAttempts int    // сколько сгенерированных кодов пробовать при конфликтах
+	Dedup             bool   // по умолчанию возвращать существующую ссылку на тот же адрес
+	BatchMaxItems     int    // предел элементов в POST /v1/shorten/batch
+	RedirectCode      int    // код редиректа для ссылок без собственного
+	PrelaunchMode     string // поведение до starts_at для ссылок без собственного режима
+	ComingSoonURL     string // куда вести в режиме coming_soon; пусто — встроенная страница
+	ExpiredURL        string // куда вести после expires_at, если у ссылки нет своего адреса
+	NotFoundURL       string // куда вести по несуществующей ссылке; пусто — 404
+	BaseURL           string // внешний адрес сервиса для коротких ссылок; пусто — из запроса
+	QRCacheTTL        time.Duration
+	ReservedWords     []string // дополнительно к defaultReservedWords
+
+	PasswordSecret        []byte        // ключ подписи cookie доступа к ссылкам с паролем
+	PasswordCookieTTL     time.Duration // сколько действует введённый пароль
+	PasswordMaxAttempts   int           // неудачных попыток ввода пароля с одного IP за окно
+	PasswordAttemptWindow time.Duration
+}
+
+// GeoLocator определяет местоположение посетителя по IP
+type GeoLocator interface {
+	Lookup(ip string) geoip.Location
 }
 
 type service struct {
-	repo repo.Repository
-	log  *zerolog.Logger
-	rdb  *redis.Client
+	repo     repo.Repository
+	log      *zerolog.Logger
+	rdb      *redis.Client
+	codes    shortcode.Generator
+	geo      GeoLocator  // nil — GeoIP отключён
+	screener URLScreener // nil — адреса назначения не проверяются
+	cfg      Config
+
+	reserved map[string]bool // коды, недоступные для алиасов и корня
+	domains  atomic.Pointer[domainSet]
 }
 
-func NewService(repo repo.Repository, logger *zerolog.Logger, rdb *redis.Client) Service {
+func NewService(repo repo.Repository, logger *zerolog.Logger, rdb *redis.Client, codes shortcode.Generator, geo GeoLocator, screener URLScreener, cfg Config) Service {
+	if cfg.ShortCodeAttempts < 1 {
+		cfg.ShortCodeAttempts = 1
+	}
+	if cfg.BatchMaxItems < 1 {
+		cfg.BatchMaxItems = 1000
+	}
+	if cfg.RedirectCode == 0 {
+		cfg.RedirectCode = 302
+	}
+	if cfg.PrelaunchMode == "" {
+		cfg.PrelaunchMode = defaultPrelaunchMode
+	}
+	if cfg.QRCacheTTL <= 0 {
+		cfg.QRCacheTTL = 24 * time.Hour
+	}
+	if cfg.PasswordCookieTTL <= 0 {
+		cfg.PasswordCookieTTL = time.Hour
+	}
+	if cfg.PasswordMaxAttempts < 1 {
+		cfg.PasswordMaxAttempts = 5
+	}
+	if cfg.PasswordAttemptWindow <= 0 {
+		cfg.PasswordAttemptWindow = 15 * time.Minute
+	}
 	return &service{
-		repo: repo,
-		log:  logger,
-		rdb:  rdb,
+		repo:  repo,
+		log:   logger,
+		rdb:   rdb,
+		codes: codes,
+		geo:   geo,
+		cfg:   cfg,
+
+		screener: screener,
+
+		reserved: reservedSet(cfg.ReservedWords),
 	}
 }
 
+// createUrlRequest — тело POST /v1/shorten и элемент пакетного создания
+type createUrlRequest struct {
+	Original        string       `json:"original" validate:"required,url"`
+	CustomAlias     *string      `json:"custom_alias,omitempty" validate:"omitempty,alphanum,min=3,max=30"`
+	ExpiresAt       *time.Time   `json:"expires_at,omitempty"`
+	Dedup           *bool        `json:"dedup,omitempty"`
+	RedirectCode    *int         `json:"redirect_code,omitempty" validate:"omitempty,oneof=301 302 307 308"` // 301/308 — постоянный, 307/308 сохраняют метод
+	QueryForwarding string       `json:"query_forwarding,omitempty" validate:"omitempty,oneof=off merge override"`
+	UTM             *UTM         `json:"utm,omitempty"`
+	Rules           []TargetRule `json:"rules,omitempty" validate:"omitempty,max=20,dive"`
+	Variants        []Variant    `json:"variants,omitempty" validate:"omitempty,min=2,max=10,dive"`
+	Password        *string      `json:"password,omitempty" validate:"omitempty,min=4,max=72"` // bcrypt учитывает только 72 байта
+	MaxClicks       *int         `json:"max_clicks,omitempty" validate:"omitempty,gte=1"`      // 1 — одноразовая ссылка
+	StartsAt        *time.Time   `json:"starts_at,omitempty"`
+	PrelaunchMode   *string      `json:"prelaunch,omitempty" validate:"omitempty,oneof=not_found coming_soon fallback"`
+	PrelaunchURL    *string      `json:"prelaunch_url,omitempty" validate:"omitempty,url"`
+	ExpiredURL      *string      `json:"expired_url,omitempty" validate:"omitempty,url"`
+	Title           *string      `json:"title,omitempty" validate:"omitempty,max=200"`
+	Interstitial    bool         `json:"interstitial,omitempty"` // предупреждать перед уходом на адрес назначения
+	DeepLink        *DeepLink    `json:"deep_link,omitempty"`
+	Domain          string       `json:"domain,omitempty" validate:"omitempty,max=253"` // зарегистрированный домен; пусто — домен по умолчанию
+
+	short string // код из выгрузки: задаёт только импорт, через API код выбирается алиасом
+}
+
 func (s *service) CreateUrl(ctx *ginext.Context) {
-	var req struct {
-		Original    string     `json:"original" validate:"required,url"`
-		CustomAlias *string    `json:"custom_alias,omitempty" validate:"omitempty,alphanum,min=3,max=30"`
-		ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	owner := principal(ctx)
+	if owner == nil {
+		return
 	}
 
+	var req createUrlRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		s.log.Error().Msgf("Invalid request body: %v", err)
 		dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
@@ -56,101 +166,389 @@ func (s *service) CreateUrl(ctx *ginext.Context) {
 		return
 	}
 
-	short := ""
-	if req.CustomAlias != nil {
-		short = *req.CustomAlias
-	} else {
-		short = generateShortCode(6)
-	}
-
-	urlEntity := repo.UrlEntity{
-		Short:       short,
-		Original:    req.Original,
-		CustomAlias: req.CustomAlias,
-		CreatedAt:   time.Now(),
-		ExpiresAt:   req.ExpiresAt,
-	}
-
-	id, err := s.repo.CreateUrl(ctx.Request.Context(), urlEntity)
+	url, created, err := s.createLink(ctx.Request.Context(), s.repo, owner, req)
 	if err != nil {
-		var pgErr *pq.Error
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" && req.CustomAlias != nil {
-			dto.BadResponseError(ctx, dto.FieldIncorrect, "Custom alias already exists")
+		var le *linkError
+		if errors.As(err, &le) {
+			dto.ErrorResponse(ctx, le.Status, le.Code, le.Desc)
 			return
 		}
 		s.log.Error().Msgf("Failed to create URL: %v", err)
 		dto.InternalServerError(ctx)
 		return
 	}
-	urlEntity.ID = id
+	if !created {
+		s.withShortURL(ctx, &url)
+		dto.SuccessResponse(ctx, url)
+		return
+	}
 
-	url := toServiceUrl(urlEntity)
+	s.cacheUrl(ctx.Request.Context(), url)
 
-	if s.rdb != nil {
-		key := fmt.Sprintf("url:%s", url.Short)
-		data, _ := json.Marshal(url)
+	s.withShortURL(ctx, &url)
+	dto.SuccessCreatedResponse(ctx, url)
+}
+
+// linkError — ошибка создания ссылки, о которой можно сообщить клиенту
+type linkError struct {
+	Status int
+	Code   string
+	Desc   string
+}
+
+func (e *linkError) Error() string {
+	return e.Desc
+}
+
+var (
+	errAliasTaken      = &linkError{Status: 400, Code: dto.ShortAlreadyExists, Desc: "Short alias already exists"}
+	errAliasReserved   = &linkError{Status: 400, Code: dto.ShortReserved, Desc: "Short alias is reserved"}
+	errShortTaken      = &linkError{Status: 400, Code: dto.ShortAlreadyExists, Desc: "Short code already exists"}
+	errShortReserved   = &linkError{Status: 400, Code: dto.ShortReserved, Desc: "Short code is reserved"}
+	errShortsExhausted = errors.New("failed to generate a unique short code")
+)
+
+// createLink создаёт ссылку через r — обычный или транзакционный репозиторий.
+// created=false означает, что в режиме дедупликации найдена существующая ссылка.
+// Кэш не трогается: вызывающий кэширует ссылку после успешной фиксации.
+func (s *service) createLink(ctx context.Context, r repo.Repository, owner *APIKey, req createUrlRequest) (Url, bool, error) {
+	if req.CustomAlias != nil && s.isReserved(*req.CustomAlias) {
+		return Url{}, false, errAliasReserved
+	}
+	if req.short != "" && s.isReserved(req.short) {
+		return Url{}, false, errShortReserved
+	}
+	if err := checkRules(req.Rules); err != nil {
+		return Url{}, false, err
+	}
+	if err := checkVariants(req.Variants); err != nil {
+		return Url{}, false, err
+	}
+	if err := checkSchedule(req.StartsAt, req.ExpiresAt, req.PrelaunchMode, req.PrelaunchURL); err != nil {
+		return Url{}, false, err
+	}
+	if err := checkDeepLink(req.DeepLink); err != nil {
+		return Url{}, false, err
+	}
+	targets := linkTargets(&req.Original, req.Rules, req.Variants, req.PrelaunchURL, req.ExpiredURL, req.DeepLink)
+	if err := s.screenTargets(ctx, targets); err != nil {
+		return Url{}, false, err
+	}
+
+	var domainID *int64
+	if req.Domain != "" {
+		domain, err := s.linkDomain(ctx, owner, req.Domain)
+		if err != nil {
+			return Url{}, false, err
+		}
+		domainID = &domain.ID
+	}
+
+	// Дедупликация: для простой ссылки без алиаса отдаём уже существующую простую ссылку на том же домене.
+	// Ссылку с паролем, лимитом, расписанием или правилами подменять нельзя: клиент получил бы
+	// ссылку без защиты, которую просил.
+	dedup := s.cfg.Dedup
+	if req.Dedup != nil {
+		dedup = *req.Dedup
+	}
+	if dedup && req.CustomAlias == nil && req.short == "" && isPlainLink(req) {
+		existing, err := r.FindActiveUrlByOriginal(ctx, owner.WorkspaceID, domainKey(domainID), req.Original)
+		if err != nil {
+			return Url{}, false, fmt.Errorf("failed to look up existing URL: %w", err)
+		}
+		if existing != nil {
+			return toServiceUrl(*existing), false, nil
+		}
+	}
+
+	urlEntity := repo.UrlEntity{
+		Short:           req.short,
+		Original:        req.Original,
+		CustomAlias:     req.CustomAlias,
+		CreatedAt:       time.Now(),
+		ExpiresAt:       req.ExpiresAt,
+		WorkspaceID:     owner.WorkspaceID,
+		APIKeyID:        &owner.ID,
+		RedirectCode:    req.RedirectCode,
+		QueryForwarding: req.QueryForwarding,
+		UTM:             toRepoUTM(req.UTM),
+		Rules:           toRepoRules(req.Rules),
+		Variants:        toRepoVariants(req.Variants),
+		MaxClicks:       req.MaxClicks,
+		StartsAt:        req.StartsAt,
+		PrelaunchMode:   req.PrelaunchMode,
+		PrelaunchURL:    req.PrelaunchURL,
+		ExpiredURL:      req.ExpiredURL,
+		Title:           req.Title,
+		Interstitial:    req.Interstitial,
+		DeepLink:        toRepoDeepLink(req.DeepLink),
+		DomainID:        domainID,
+	}
+	if req.Title != nil && *req.Title == "" {
+		urlEntity.Title = nil
+	}
+	if req.Password != nil {
+		hash, err := hashPassword(*req.Password)
+		if err != nil {
+			return Url{}, false, err
+		}
+		urlEntity.PasswordHash = &hash
+	}
+
+	if err := s.insertUrl(ctx, r, &urlEntity); err != nil {
+		return Url{}, false, err
+	}
+
+	return toServiceUrl(urlEntity), true, nil
+}
+
+func (s *service) cacheUrl(ctx context.Context, url Url) {
+	if s.rdb == nil {
+		return
+	}
+
+	data, _ := json.Marshal(cachedUrl{Url: url, PasswordVersion: url.PasswordVersion})
+	for _, key := range urlCacheKeys(url.DomainID, url.Short, url.CustomAlias) {
 		if err := s.rdb.Set(ctx, key, string(data)); err != nil {
 			s.log.Warn().Msgf("Failed to cache URL in Redis: %v", err)
 		}
 	}
+}
 
-	dto.SuccessCreatedResponse(ctx, url)
+// cachedUrl — запись ссылки в Redis: в ответах API отпечатка пароля нет, а в кэше он нужен
+type cachedUrl struct {
+	Url
+	PasswordVersion string `json:"password_version,omitempty"`
 }
 
-func generateShortCode(n int) string {
-	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	rand.Seed(time.Now().UnixNano())
-	b := make([]byte, n)
-	for i := range b {
-		b[i] = letters[rand.Intn(len(letters))]
+// urlCacheKey — ключ ссылки в Redis; ссылки брендированных доменов хранятся отдельно,
+// потому что алиасы уникальны только в пределах домена
+func urlCacheKey(domainID int64, code string) string {
+	if domainID == 0 {
+		return fmt.Sprintf("url:%s", code)
 	}
-	return string(b)
+	return fmt.Sprintf("url:%d:%s", domainID, code)
 }
 
-func (s *service) Redirect(ctx *ginext.Context) {
-	short := ctx.Param("short_url")
-	if short == "" {
-		dto.FieldIncorrectError(ctx, "short_url")
-		return
+// urlCacheKeys — все ключи, по которым ссылка может лежать в кэше
+func urlCacheKeys(domainID *int64, short string, alias *string) []string {
+	keys := []string{urlCacheKey(domainKey(domainID), short)}
+	if alias != nil && *alias != short {
+		keys = append(keys, urlCacheKey(domainKey(domainID), *alias))
 	}
+	return keys
+}
 
-	if s.rdb != nil {
-		key := fmt.Sprintf("url:%s", short)
-		if data, err := s.rdb.Get(ctx, key); err == nil {
-			var url Url
-			if err := json.Unmarshal([]byte(data), &url); err == nil {
-				if url.ExpiresAt != nil && url.ExpiresAt.Before(time.Now()) {
-					dto.FieldIncorrectError(ctx, "url")
-					return
+// isPlainLink сообщает, что у ссылки нет собственных настроек кроме адреса и домена
+func isPlainLink(req createUrlRequest) bool {
+	return req.ExpiresAt == nil && req.RedirectCode == nil &&
+		(req.QueryForwarding == "" || req.QueryForwarding == "off") && req.UTM == nil &&
+		len(req.Rules) == 0 && len(req.Variants) == 0 &&
+		req.Password == nil && req.MaxClicks == nil &&
+		req.StartsAt == nil && req.PrelaunchMode == nil && req.PrelaunchURL == nil && req.ExpiredURL == nil &&
+		req.Title == nil && !req.Interstitial && req.DeepLink == nil
+}
+
+// insertUrl сохраняет ссылку, заполняя Short и ID. Для кастомного алиаса конфликт
+// возвращается как errAliasTaken, сгенерированный код при конфликте перевыпускается.
+// На домене по умолчанию алиас служит и short; на брендированном домене short генерируется.
+// Код ссылки на домене — short или алиас — не совпадает с кодом другой ссылки (триггер urls_check_code),
+// поэтому конфликт с алиасом уточняется через AliasTaken, а конфликт short — перевыпуском.
+func (s *service) insertUrl(ctx context.Context, r repo.Repository, url *repo.UrlEntity) error {
+	// код задан заранее (импорт выгрузки): вставляется как есть, без подбора другого
+	if url.Short != "" {
+		id, err := r.CreateUrl(ctx, *url)
+		if err != nil {
+			if !isUniqueViolation(err) {
+				return err
+			}
+			if url.CustomAlias != nil {
+				taken, err := r.AliasTaken(ctx, domainKey(url.DomainID), *url.CustomAlias)
+				if err != nil {
+					return err
+				}
+				if taken {
+					return errAliasTaken
 				}
-				ip, ua, referer := getUserInfo(ctx)
-				s.recordClick(ctx, url.Short, ip, ua, referer)
-				ctx.Redirect(302, url.Original)
-				return
 			}
+			return errShortTaken
 		}
+		url.ID = id
+		return nil
 	}
 
-	// Получение из БД
-	entity, err := s.repo.GetUrlByShort(ctx.Request.Context(), short)
+	if url.CustomAlias != nil && url.DomainID == nil {
+		url.Short = *url.CustomAlias
+		id, err := r.CreateUrl(ctx, *url)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return errAliasTaken
+			}
+			return err
+		}
+		url.ID = id
+		return nil
+	}
+
+	for attempt := 0; attempt < s.cfg.ShortCodeAttempts; attempt++ {
+		code, err := s.codes.Generate(ctx, attempt)
+		if err != nil {
+			return err
+		}
+		if s.isReserved(code) {
+			// в корне такой код перекрыт маршрутом сервиса
+			continue
+		}
+		url.Short = code
+
+		id, err := r.CreateUrl(ctx, *url)
+		if err == nil {
+			url.ID = id
+			return nil
+		}
+		if !isUniqueViolation(err) {
+			return err
+		}
+		if url.CustomAlias != nil {
+			taken, err := r.AliasTaken(ctx, *url.DomainID, *url.CustomAlias)
+			if err != nil {
+				return err
+			}
+			if taken {
+				return errAliasTaken
+			}
+		}
+		s.log.Warn().Msgf("Short code %s collided, retrying (attempt %d)", code, attempt+1)
+	}
+
+	return errShortsExhausted
+}
+
+func isUniqueViolation(err error) bool {
+	if errors.Is(err, repo.ErrUrlConflict) {
+		return true
+	}
+	var pgErr *pq.Error
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func (s *service) Redirect(ctx *ginext.Context) {
+	short := ctx.Param("short_url")
+	// "abc+" — предпросмотр ссылки вместо перехода
+	short, preview := strings.CutSuffix(short, "+")
+	if short == "" {
+		dto.FieldIncorrectError(ctx, "short_url")
+		return
+	}
+
+	url, err := s.resolveUrl(ctx.Request.Context(), s.hostDomainID(ctx), short)
 	if err != nil {
 		s.log.Error().Msgf("failed to get URL: %v", err)
-		dto.ShortNotFoundError(ctx)
+		dto.InternalServerError(ctx)
 		return
 	}
-	if entity == nil {
-		dto.ShortNotFoundError(ctx)
+	if url == nil {
+		s.serveMissing(ctx)
+		return
+	}
+	if url.ExpiresAt != nil && url.ExpiresAt.Before(time.Now()) {
+		s.serveExpired(ctx, *url)
+		return
+	}
+	if notStarted(*url) {
+		s.servePrelaunch(ctx, *url)
 		return
 	}
-	if entity.ExpiresAt != nil && entity.ExpiresAt.Before(time.Now()) {
-		dto.FieldIncorrectError(ctx, "url")
+	if url.PasswordProtected && !s.hasLinkAccess(ctx, url.Short, url.PasswordVersion) {
+		s.renderPasswordForm(ctx, 200, "")
+		return
+	}
+	if preview {
+		s.servePreview(ctx, *url)
 		return
 	}
 
-	ip, ua, referer := getUserInfo(ctx)
-	s.recordClick(ctx, entity.Short, ip, ua, referer)
+	v := newVisit(ctx)
+	if s.geo != nil {
+		v.Location = s.geo.Lookup(v.IP)
+	}
+
+	// правило таргетинга подменяет адрес назначения, иначе его выбирает A/B-тест;
+	// UTM и параметры перехода применяются к выбранному адресу
+	target := *url
+	if ruleURL := matchRule(url.Rules, v); ruleURL != "" {
+		target.Original = ruleURL
+	} else if variant := pickVariant(ctx, url.Short, url.Variants, v); variant != nil {
+		target.Original = variant.URL
+		v.Variant = variant.Name
+	}
+	// метка сканирования QR-кода нужна только аналитике и на адрес назначения не уходит
+	query := ctx.Request.URL.Query()
+	if query.Get("src") == repo.ClickSourceQR {
+		query.Del("src")
+		v.Source = repo.ClickSourceQR
+	}
+	destination, campaign := buildDestination(target, query)
 
-	ctx.Redirect(302, entity.Original)
+	// Лимит расходуется в БД одним условным UPDATE, поэтому ни кэш, ни параллельные
+	// переходы не позволят превысить max_clicks
+	if url.MaxClicks != nil {
+		ok, err := s.repo.ConsumeClick(ctx.Request.Context(), url.ID)
+		if err != nil {
+			s.log.Error().Msgf("Failed to consume click for short=%s: %v", url.Short, err)
+			dto.InternalServerError(ctx)
+			return
+		}
+		if !ok {
+			s.serveExhausted(ctx)
+			return
+		}
+	}
+
+	v.UTMCampaign = campaign
+	s.recordClick(ctx, url.Short, v)
+
+	// на телефоне сначала пробуем открыть приложение, адрес назначения остаётся запасным
+	if app, fallback := appTarget(url.DeepLink, v, destination); app != "" {
+		s.serveDeepLink(ctx, app, fallback)
+		return
+	}
+	if url.Interstitial {
+		s.serveInterstitial(ctx, *url, destination)
+		return
+	}
+	ctx.Redirect(s.redirectCode(url.RedirectCode), destination)
+}
+
+// resolveUrl ищет активную ссылку домена сначала в Redis, затем в БД; nil — ссылки нет
+func (s *service) resolveUrl(ctx context.Context, domainID int64, short string) (*Url, error) {
+	if s.rdb != nil {
+		key := urlCacheKey(domainID, short)
+		if data, err := s.rdb.Get(ctx, key); err == nil {
+			var cached cachedUrl
+			if err := json.Unmarshal([]byte(data), &cached); err == nil {
+				url := cached.Url
+				url.PasswordVersion = cached.PasswordVersion
+				return &url, nil
+			}
+		}
+	}
+
+	// Получение из БД
+	entity, err := s.repo.GetUrlByShort(ctx, domainID, short)
+	if err != nil || entity == nil {
+		return nil, err
+	}
+	url := toServiceUrl(*entity)
+	return &url, nil
+}
+
+// redirectCode возвращает код редиректа ссылки или код по умолчанию из конфига
+func (s *service) redirectCode(code *int) int {
+	if code == nil {
+		return s.cfg.RedirectCode
+	}
+	return *code
 }
 
 func parseUserAgent(uaString string) (browser, os, device string) {
@@ -171,7 +569,30 @@ func parseUserAgent(uaString string) (browser, os, device string) {
 	return name, os, device
 }
 
-func (s *service) recordClick(ctx context.Context, short, ip, ua, referer string) {
+// maxCampaignLength — размер clicks.utm_campaign. Метка приходит из адреса перехода или назначения
+// и ничем не ограничена, а слишком длинное значение не даст сохранить клик целиком.
+const maxCampaignLength = 100
+
+// truncateRunes обрезает строку до n символов, не разрезая многобайтовые
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// visit — данные перехода, которые сохраняются в clicks
+type visit struct {
+	IP          string
+	UA          string
+	Referer     string
+	UTMCampaign string
+	Location    geoip.Location
+	Variant     string
+	Source      string
+}
+
+func (s *service) recordClick(ctx context.Context, short string, v visit) {
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
@@ -183,17 +604,34 @@ func (s *service) recordClick(ctx context.Context, short, ip, ua, referer string
 			ctx = context.Background()
 		}
 
-		browser, os, device := parseUserAgent(ua)
+		browser, os, device := parseUserAgent(v.UA)
 
 		click := repo.ClickEntity{
 			Short:     short,
 			CreatedAt: time.Now(),
-			IP:        &ip,
-			RawUA:     &ua,
-			Referer:   &referer,
+			IP:        &v.IP,
+			RawUA:     &v.UA,
+			Referer:   &v.Referer,
 			Browser:   &browser,
 			OS:        &os,
 			Device:    &device,
+			Source:    v.Source,
+		}
+		if v.UTMCampaign != "" {
+			campaign := truncateRunes(v.UTMCampaign, maxCampaignLength)
+			click.UTMCampaign = &campaign
+		}
+		if v.Location.Country != "" {
+			click.Country = &v.Location.Country
+		}
+		if v.Location.Region != "" {
+			click.Region = &v.Location.Region
+		}
+		if v.Location.City != "" {
+			click.City = &v.Location.City
+		}
+		if v.Variant != "" {
+			click.Variant = &v.Variant
 		}
 
 		if err := s.repo.CreateClick(ctx, click); err != nil {
@@ -202,28 +640,43 @@ func (s *service) recordClick(ctx context.Context, short, ip, ua, referer string
 	}()
 }
 
-func getUserInfo(ctx *ginext.Context) (ip, ua, referer string) {
-	ip = ctx.ClientIP()
-	ua = ctx.GetHeader("User-Agent")
-	referer = ctx.GetHeader("Referer")
-	return
+func newVisit(ctx *ginext.Context) visit {
+	return visit{
+		IP:      ctx.ClientIP(),
+		UA:      ctx.GetHeader("User-Agent"),
+		Referer: ctx.GetHeader("Referer"),
+		Source:  repo.ClickSourceLink,
+	}
 }
+
 func (s *service) ShowAnalytics(ctx *ginext.Context) {
+	owner := principal(ctx)
+	if owner == nil {
+		return
+	}
+
 	short := ctx.Param("short_url")
 	if short == "" {
 		dto.FieldIncorrectError(ctx, "short_url")
 		return
 	}
 
-	// Проверка существования ссылки
-	entity, err := s.repo.GetUrlByShort(ctx.Request.Context(), short)
+	domainID, ok := s.ownedDomainID(ctx, owner)
+	if !ok {
+		return
+	}
+
+	// Проверка, что ссылка принадлежит workspace (удалённые тоже доступны для отчётов)
+	entity, err := s.repo.GetOwnedUrl(ctx.Request.Context(), owner.WorkspaceID, domainID, short, true)
 	if err != nil || entity == nil {
 		dto.ShortNotFoundError(ctx)
 		return
 	}
+	// клики хранятся по основному short, а в пути мог прийти алиас
+	short = entity.Short
 
 	var req struct {
-		By    string `json:"by,omitempty"`    // "day", "month", "browser", "os", "device"
+		By    string `json:"by,omitempty"`    // "day", "month", "browser", "os", "device", "utm_campaign", "country", "region", "city", "variant", "source"
 		Value string `json:"value,omitempty"` // дата "YYYY-MM-DD" или "YYYY-MM" для месяца
 	}
 	_ = ctx.ShouldBindJSON(&req)
@@ -265,7 +718,7 @@ func (s *service) ShowAnalytics(ctx *ginext.Context) {
 		}
 		dto.SuccessResponse(ctx, data)
 
-	case "browser", "os", "device":
+	case "browser", "os", "device", "utm_campaign", "country", "region", "city", "variant", "source":
 		data, period, err := s.repo.GetAnalyticsByField(ctx.Request.Context(), short, req.By)
 		if err != nil {
 			dto.InternalServerError(ctx)
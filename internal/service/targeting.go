@@ -0,0 +1,85 @@
+package service
+
+import (
+	"fmt"
+	"secondOne/internal/dto"
+	"slices"
+	"strings"
+)
+
+// TargetRule направляет посетителя на URL, если совпали все заданные условия.
+// Значения os: ios, android, windows, macos, linux; device: mobile, desktop, bot;
+// countries — коды ISO 3166-1 alpha-2, правило срабатывает для любой страны из списка.
+type TargetRule struct {
+	OS        string   `json:"os,omitempty" validate:"omitempty,oneof=ios android windows macos linux"`
+	Device    string   `json:"device,omitempty" validate:"omitempty,oneof=mobile desktop bot"`
+	Browser   string   `json:"browser,omitempty" validate:"omitempty,max=50"`
+	Countries []string `json:"countries,omitempty" validate:"omitempty,max=50,dive,len=2,alpha"`
+	URL       string   `json:"url" validate:"required,url"`
+}
+
+// checkRules проверяет то, что не выражается тегами валидатора: у каждого правила есть условие.
+// Коды стран приводятся к верхнему регистру.
+func checkRules(rules []TargetRule) *linkError {
+	for i, rule := range rules {
+		for j, country := range rule.Countries {
+			rules[i].Countries[j] = strings.ToUpper(country)
+		}
+		if rule.OS == "" && rule.Device == "" && rule.Browser == "" && len(rule.Countries) == 0 {
+			return &linkError{
+				Status: 400,
+				Code:   dto.FieldIncorrect,
+				Desc:   fmt.Sprintf("Rule %d has no conditions, 'original' is already the fallback", i),
+			}
+		}
+	}
+	return nil
+}
+
+// matchRule возвращает URL первого правила, подходящего под посетителя, или "" — тогда используется original
+func matchRule(rules []TargetRule, v visit) string {
+	if len(rules) == 0 {
+		return ""
+	}
+
+	browser, os, device := parseUserAgent(v.UA)
+	family := osFamily(os)
+	device = strings.ToLower(device)
+
+	for _, rule := range rules {
+		if rule.OS != "" && rule.OS != family {
+			continue
+		}
+		if rule.Device != "" && rule.Device != device {
+			continue
+		}
+		if rule.Browser != "" && !strings.EqualFold(rule.Browser, browser) {
+			continue
+		}
+		// без GeoIP страна неизвестна, и правила по странам не срабатывают
+		if len(rule.Countries) > 0 && !slices.Contains(rule.Countries, v.Location.Country) {
+			continue
+		}
+		return rule.URL
+	}
+	return ""
+}
+
+// osFamily сводит строку ОС из useragent ("CPU iPhone OS 17_1 like Mac OS X", "Android 13") к семейству
+func osFamily(os string) string {
+	os = strings.ToLower(os)
+	switch {
+	case strings.Contains(os, "iphone"), strings.Contains(os, "ipad"), strings.Contains(os, "ipod"),
+		strings.Contains(os, "like mac os x"):
+		return "ios"
+	case strings.Contains(os, "android"):
+		return "android"
+	case strings.Contains(os, "windows"):
+		return "windows"
+	case strings.Contains(os, "mac os"):
+		return "macos"
+	case strings.Contains(os, "linux"):
+		return "linux"
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"secondOne/pkg/geoip"
+	"testing"
+)
+
+const (
+	uaIPhone  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1"
+	uaAndroid = "Mozilla/5.0 (Linux; Android 13; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Mobile Safari/537.36"
+	uaWindows = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36"
+	uaMacFF   = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:120.0) Gecko/20100101 Firefox/120.0"
+	uaBot     = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+)
+
+func TestOSFamily(t *testing.T) {
+	tests := []struct {
+		os   string
+		want string
+	}{
+		{"CPU iPhone OS 17_1 like Mac OS X", "ios"},
+		{"CPU OS 16_0 like Mac OS X", "ios"},
+		{"Android 13", "android"},
+		{"Windows 10", "windows"},
+		{"Intel Mac OS X 10.15", "macos"},
+		{"Linux x86_64", "linux"},
+		{"", ""},
+		{"Unknown", ""},
+	}
+	for _, tt := range tests {
+		if got := osFamily(tt.os); got != tt.want {
+			t.Errorf("osFamily(%q) = %q, want %q", tt.os, got, tt.want)
+		}
+	}
+}
+
+func TestMatchRule(t *testing.T) {
+	rules := []TargetRule{
+		{OS: "ios", URL: "https://apps.example.com/ios"},
+		{OS: "android", Countries: []string{"DE", "AT"}, URL: "https://example.de/android"},
+		{Device: "mobile", URL: "https://m.example.com"},
+		{Browser: "firefox", URL: "https://example.com/firefox"},
+		{Countries: []string{"RU"}, URL: "https://example.ru"},
+	}
+
+	tests := []struct {
+		name    string
+		ua      string
+		country string
+		want    string
+	}{
+		{"first matching rule wins", uaIPhone, "RU", "https://apps.example.com/ios"},
+		{"all conditions must match", uaAndroid, "DE", "https://example.de/android"},
+		{"falls through to the next rule", uaAndroid, "FR", "https://m.example.com"},
+		{"browser is case-insensitive", uaMacFF, "", "https://example.com/firefox"},
+		{"country only", uaWindows, "RU", "https://example.ru"},
+		{"unknown country does not match country rules", uaWindows, "", ""},
+		{"no rule matches", uaWindows, "US", ""},
+		{"bot is not mobile", uaBot, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := visit{UA: tt.ua, Location: geoip.Location{Country: tt.country}}
+			if got := matchRule(rules, v); got != tt.want {
+				t.Fatalf("matchRule() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := matchRule(nil, visit{UA: uaIPhone}); got != "" {
+		t.Fatalf("matchRule(nil) = %q, want empty", got)
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	rules := []TargetRule{{Countries: []string{"de", "At"}, URL: "https://example.de"}}
+	if err := checkRules(rules); err != nil {
+		t.Fatalf("checkRules() = %v, want nil", err)
+	}
+	if rules[0].Countries[0] != "DE" || rules[0].Countries[1] != "AT" {
+		t.Fatalf("countries are not upper-cased: %v", rules[0].Countries)
+	}
+
+	if err := checkRules([]TargetRule{{URL: "https://example.com"}}); err == nil {
+		t.Fatal("checkRules() accepted a rule without conditions")
+	}
+}
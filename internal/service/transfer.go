@@ -0,0 +1,420 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wb-go/wbf/ginext"
+	"io"
+	"mime"
+	"path/filepath"
+	"secondOne/internal/dto"
+	"secondOne/internal/repo"
+	"secondOne/pkg/shortcode"
+	"secondOne/pkg/validator"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+
+	maxImportErrors  = 100
+	maxNDJSONLineLen = 1 << 20
+	// как часто сбрасывать буфер экспорта клиенту
+	exportFlushEvery = 500
+)
+
+// importRow — строка импорта до валидации; line — номер строки во входном файле
+type importRow struct {
+	line int
+	req  createUrlRequest
+	err  error
+}
+
+// ImportLinks создаёт ссылки из CSV (short, original, custom_alias, expires_at, domain) или NDJSON.
+// Файл читается потоково; каждая строка создаётся отдельно, ошибки собираются построчно.
+// Код из колонки short сохраняется как есть: ссылки из выгрузки открываются по прежним адресам,
+// а занятый код — ошибка строки, а не новый код.
+func (s *service) ImportLinks(ctx *ginext.Context) {
+	owner := principal(ctx)
+	if owner == nil {
+		return
+	}
+
+	body := io.Reader(ctx.Request.Body)
+	filename := ""
+	if strings.HasPrefix(ctx.ContentType(), "multipart/form-data") {
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			dto.FieldIncorrectError(ctx, "file")
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			s.log.Error().Msgf("Failed to open uploaded file: %v", err)
+			dto.InternalServerError(ctx)
+			return
+		}
+		defer file.Close()
+		body = file
+		filename = header.Filename
+	}
+
+	format := detectFormat(ctx.Query("format"), ctx.ContentType(), filename)
+	if format == "" {
+		dto.BadResponseError(ctx, dto.FieldIncorrect, "'format' must be csv or ndjson")
+		return
+	}
+
+	result := ImportResult{Format: format}
+	handle := func(row importRow) {
+		result.Rows++
+		if row.err == nil {
+			row.err = validator.Validate(ctx.Request.Context(), row.req)
+			if row.err != nil {
+				row.err = &linkError{Status: 400, Code: dto.FieldIncorrect, Desc: row.err.Error()}
+			}
+		}
+
+		var (
+			url     Url
+			created bool
+		)
+		if row.err == nil {
+			url, created, row.err = s.createLink(ctx.Request.Context(), s.repo, owner, row.req)
+		}
+
+		if row.err != nil {
+			result.Failed++
+			if len(result.Errors) < maxImportErrors {
+				result.Errors = append(result.Errors, importError(row, s))
+			}
+			return
+		}
+
+		if created {
+			result.Created++
+			s.cacheUrl(ctx.Request.Context(), url)
+		} else {
+			result.Existing++
+		}
+	}
+
+	var err error
+	if format == formatCSV {
+		err = readCSV(body, handle)
+	} else {
+		err = readNDJSON(body, handle)
+	}
+	if err != nil {
+		// разбор прерван: уже созданные строки остаются, сообщаем, где остановились
+		s.log.Warn().Msgf("Import stopped after %d rows: %v", result.Rows, err)
+		dto.ErrorResponse(ctx, 400, dto.FieldBadFormat, fmt.Sprintf("Import stopped after %d rows: %v", result.Rows, err))
+		return
+	}
+
+	s.log.Info().Msgf("Imported %d links (%d existing, %d failed) into workspace %d",
+		result.Created, result.Existing, result.Failed, owner.WorkspaceID)
+
+	dto.SuccessResponse(ctx, result)
+}
+
+func importError(row importRow, s *service) ImportRowError {
+	e := ImportRowError{Line: row.line, Value: row.req.Original}
+	var le *linkError
+	if errors.As(row.err, &le) {
+		e.Code, e.Desc = le.Code, le.Desc
+		return e
+	}
+	s.log.Error().Msgf("Failed to import line %d: %v", row.line, row.err)
+	e.Code, e.Desc = dto.ServiceUnavailable, dto.InternalError
+	return e
+}
+
+func detectFormat(param, contentType, filename string) string {
+	switch strings.ToLower(param) {
+	case formatCSV:
+		return formatCSV
+	case formatNDJSON, "jsonl":
+		return formatNDJSON
+	case "":
+	default:
+		return ""
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "text/csv":
+			return formatCSV
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			return formatNDJSON
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return formatCSV
+	case ".ndjson", ".jsonl":
+		return formatNDJSON
+	}
+	return ""
+}
+
+// csvColumns сопоставляет названия колонок (в т.ч. из выгрузок bit.ly) с полями запроса
+var csvColumns = map[string]string{
+	"original":     "original",
+	"long_url":     "original",
+	"url":          "original",
+	"custom_alias": "custom_alias",
+	"alias":        "custom_alias",
+	"keyword":      "custom_alias",
+	"expires_at":   "expires_at",
+	"expiration":   "expires_at",
+	"domain":       "domain",
+	"short":        "short",
+}
+
+func readCSV(r io.Reader, handle func(importRow)) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// без заголовка колонки идут в порядке original, custom_alias, expires_at
+	positions := map[string]int{"original": 0, "custom_alias": 1, "expires_at": 2}
+	first := true
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := reader.FieldPos(0)
+
+		if first {
+			first = false
+			if header := csvHeader(record); header != nil {
+				positions = header
+				continue
+			}
+		}
+
+		field := func(name string) string {
+			i, ok := positions[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		row := importRow{line: line}
+		row.req.Original = field("original")
+		row.req.Domain = field("domain")
+		row.req.short = field("short")
+		if alias := field("custom_alias"); alias != "" {
+			row.req.CustomAlias = &alias
+		}
+		if v := field("expires_at"); v != "" {
+			expiresAt, err := parseImportTime(v)
+			if err != nil {
+				row.err = &linkError{Status: 400, Code: dto.FieldBadFormat, Desc: "Field 'expires_at' has bad format"}
+			} else {
+				row.req.ExpiresAt = &expiresAt
+			}
+		}
+		if row.err == nil {
+			row.err = checkImportShort(row.req.short)
+		}
+		handle(row)
+	}
+}
+
+// csvHeader возвращает позиции известных колонок или nil, если строка — не заголовок
+func csvHeader(record []string) map[string]int {
+	positions := map[string]int{}
+	for i, name := range record {
+		if field, ok := csvColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			if _, dup := positions[field]; !dup {
+				positions[field] = i
+			}
+		}
+	}
+	if _, ok := positions["original"]; !ok {
+		return nil
+	}
+	return positions
+}
+
+// checkImportShort проверяет код из выгрузки: символы алфавита кодов и длина колонки short
+func checkImportShort(code string) error {
+	valid := len(code) <= shortcode.MaxLength
+	for i := 0; i < len(code) && valid; i++ {
+		c := code[i]
+		valid = c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+	}
+	if !valid {
+		return &linkError{Status: 400, Code: dto.FieldIncorrect, Desc: "Field 'short' must be up to 30 letters, digits, '-' or '_'"}
+	}
+	return nil
+}
+
+func parseImportTime(v string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported time format %q", v)
+}
+
+func readNDJSON(r io.Reader, handle func(importRow)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxNDJSONLineLen)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := strings.TrimSpace(scanner.Text())
+		if data == "" {
+			continue
+		}
+
+		// строка выгрузки: поля createUrlRequest и код ссылки
+		var record struct {
+			createUrlRequest
+			Short string `json:"short"`
+		}
+		row := importRow{line: line}
+		if err := json.Unmarshal([]byte(data), &record); err != nil {
+			row.err = &linkError{Status: 400, Code: dto.FieldBadFormat, Desc: "Invalid JSON line"}
+		} else {
+			row.req = record.createUrlRequest
+			row.req.short = record.Short
+			row.err = checkImportShort(row.req.short)
+		}
+		handle(row)
+	}
+	return scanner.Err()
+}
+
+// ExportLinks потоково выгружает ссылки workspace в CSV или NDJSON;
+// clicks=true добавляет общее число переходов по каждой ссылке.
+func (s *service) ExportLinks(ctx *ginext.Context) {
+	owner := principal(ctx)
+	if owner == nil {
+		return
+	}
+
+	format := strings.ToLower(ctx.DefaultQuery("format", formatCSV))
+	if format != formatCSV && format != formatNDJSON {
+		dto.BadResponseError(ctx, dto.FieldIncorrect, "'format' must be csv or ndjson")
+		return
+	}
+
+	withClicks := false
+	if v := ctx.Query("clicks"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			dto.FieldBadFormatError(ctx, "clicks")
+			return
+		}
+		withClicks = b
+	}
+
+	filename := fmt.Sprintf("links-%s.%s", time.Now().Format("20060102-150405"), format)
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	if format == formatCSV {
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		ctx.Header("Content-Type", "application/x-ndjson")
+	}
+	ctx.Status(200)
+
+	var (
+		write   func(repo.UrlWithClicks) error
+		flush   func() error
+		written int
+	)
+	if format == formatCSV {
+		w := csv.NewWriter(ctx.Writer)
+		// domain — колонка импорта, чтобы ссылки брендированных доменов вернулись на свой домен
+		header := []string{"short", "original", "custom_alias", "created_at", "expires_at", "domain"}
+		if withClicks {
+			header = append(header, "total_clicks")
+		}
+		_ = w.Write(header)
+
+		domains := s.loadDomains(ctx.Request.Context(), false)
+		write = func(u repo.UrlWithClicks) error {
+			var host string
+			if u.DomainID != nil {
+				host = domains.byID[*u.DomainID].Host
+			}
+			record := []string{u.Short, u.Original, derefString(u.CustomAlias), u.CreatedAt.Format(time.RFC3339), formatTimePtr(u.ExpiresAt), host}
+			if withClicks {
+				record = append(record, strconv.FormatInt(u.TotalClicks, 10))
+			}
+			return w.Write(record)
+		}
+		flush = func() error {
+			w.Flush()
+			return w.Error()
+		}
+	} else {
+		enc := json.NewEncoder(ctx.Writer)
+		write = func(u repo.UrlWithClicks) error {
+			// "domain" в строке понимает импорт, как и остальные поля createUrlRequest
+			url := toServiceUrl(u.UrlEntity)
+			s.withShortURL(ctx, &url)
+			if withClicks {
+				return enc.Encode(LinkListItem{Url: url, TotalClicks: u.TotalClicks})
+			}
+			return enc.Encode(url)
+		}
+		flush = func() error { return nil }
+	}
+
+	err := s.repo.StreamUrls(ctx.Request.Context(), owner.WorkspaceID, withClicks, func(u repo.UrlWithClicks) error {
+		if err := write(u); err != nil {
+			return err
+		}
+		written++
+		if written%exportFlushEvery == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			ctx.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		// заголовки уже отправлены, остаётся только оборвать выгрузку
+		s.log.Error().Msgf("Export failed after %d links: %v", written, err)
+		return
+	}
+	ctx.Writer.Flush()
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"net/http"
+	"net/http/httptest"
+	"secondOne/internal/dto"
+	"secondOne/internal/repo"
+	"secondOne/pkg/shortcode"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTransferService(t *testing.T, r *fakeRepo) *service {
+	t.Helper()
+	codes, err := shortcode.New(shortcode.Config{Length: 8}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := zerolog.Nop()
+	return NewService(r, &log, nil, codes, nil, nil, Config{}).(*service)
+}
+
+func newTransferContext(method, target, body string) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+	ctx.Set(APIKeyContextKey, &APIKey{ID: 1, WorkspaceID: 1})
+	return ctx, w
+}
+
+func importLinks(t *testing.T, s *service, format, body string) ImportResult {
+	t.Helper()
+	ctx, w := newTransferContext(http.MethodPost, "/v1/links/import?format="+format, body)
+	s.ImportLinks(ctx)
+
+	var resp struct {
+		Data ImportResult `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("import response %q: %v", w.Body.String(), err)
+	}
+	return resp.Data
+}
+
+func TestExportImportKeepsCodes(t *testing.T) {
+	alias, sale := "promo", "sale"
+	expires := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	links := []repo.UrlEntity{
+		{Short: "aB3dE9", Original: "https://example.com/a"},
+		{Short: alias, CustomAlias: &alias, Original: "https://example.com/b"},
+		{Short: "xYz-12_", CustomAlias: &sale, Original: "https://example.com/c", ExpiresAt: &expires},
+	}
+	for i := range links {
+		links[i].ID = int64(i + 1)
+		links[i].WorkspaceID = 1
+	}
+
+	for _, format := range []string{formatCSV, formatNDJSON} {
+		t.Run(format, func(t *testing.T) {
+			source := newTransferService(t, &fakeRepo{urls: links})
+			ctx, w := newTransferContext(http.MethodGet, "/v1/links/export?format="+format, "")
+			source.ExportLinks(ctx)
+			exported := w.Body.String()
+
+			target := &fakeRepo{}
+			s := newTransferService(t, target)
+			if got := importLinks(t, s, format, exported); got.Created != len(links) || got.Failed != 0 {
+				t.Fatalf("import result %+v, want %d created", got, len(links))
+			}
+			for i, want := range links {
+				got := target.urls[i]
+				if got.Short != want.Short || derefString(got.CustomAlias) != derefString(want.CustomAlias) {
+					t.Fatalf("link %s imported as short=%s alias=%s", want.Short, got.Short, derefString(got.CustomAlias))
+				}
+				if formatTimePtr(got.ExpiresAt) != formatTimePtr(want.ExpiresAt) {
+					t.Fatalf("link %s: expires_at %s, want %s", want.Short, formatTimePtr(got.ExpiresAt), formatTimePtr(want.ExpiresAt))
+				}
+			}
+
+			// повторный импорт не подбирает новые коды, а сообщает о занятых построчно
+			again := importLinks(t, s, format, exported)
+			if again.Created != 0 || again.Failed != len(links) || len(again.Errors) != len(links) {
+				t.Fatalf("second import result %+v, want every row failed", again)
+			}
+			for _, e := range again.Errors {
+				if e.Code != dto.ShortAlreadyExists {
+					t.Fatalf("line %d: code %s, want SHORT_ALREADY_EXISTS", e.Line, e.Code)
+				}
+			}
+			if len(target.urls) != len(links) {
+				t.Fatalf("second import created %d extra links", len(target.urls)-len(links))
+			}
+		})
+	}
+}
+
+func TestImportRejectsBadShort(t *testing.T) {
+	s := newTransferService(t, &fakeRepo{})
+
+	got := importLinks(t, s, formatCSV, "short,original\nbad/code,https://example.com\n,https://example.com/new\n")
+	if got.Created != 1 || got.Failed != 1 {
+		t.Fatalf("import result %+v, want 1 created and 1 failed", got)
+	}
+	if got.Errors[0].Line != 2 || got.Errors[0].Code != dto.FieldIncorrect {
+		t.Fatalf("error %+v, want FIELD_INCORRECT on line 2", got.Errors[0])
+	}
+}
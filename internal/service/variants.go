@@ -0,0 +1,81 @@
+package service
+
+import (
+	"fmt"
+	"github.com/wb-go/wbf/ginext"
+	"hash/fnv"
+	"secondOne/internal/dto"
+)
+
+const (
+	// variantCookiePrefix + short — cookie с именем выбранного варианта
+	variantCookiePrefix = "ab_"
+	variantCookieMaxAge = 30 * 24 * 60 * 60
+)
+
+// Variant — вариант адреса назначения в A/B-тесте; вес 0 исключает вариант из ротации
+type Variant struct {
+	Name   string `json:"name" validate:"required,max=50,alphanum"`
+	URL    string `json:"url" validate:"required,url"`
+	Weight int    `json:"weight" validate:"gte=0,lte=10000"`
+}
+
+// checkVariants проверяет уникальность имён и то, что трафик есть хотя бы у одного варианта
+func checkVariants(variants []Variant) *linkError {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	names := make(map[string]bool, len(variants))
+	total := 0
+	for _, v := range variants {
+		if names[v.Name] {
+			return &linkError{Status: 400, Code: dto.FieldIncorrect, Desc: fmt.Sprintf("Duplicate variant name %q", v.Name)}
+		}
+		names[v.Name] = true
+		total += v.Weight
+	}
+	if total == 0 {
+		return &linkError{Status: 400, Code: dto.FieldIncorrect, Desc: "At least one variant must have a positive weight"}
+	}
+	return nil
+}
+
+// pickVariant выбирает вариант для посетителя. Повторный визит попадает в тот же вариант:
+// сначала по cookie, без неё — по хэшу IP и User-Agent, так что распределение детерминировано.
+// Выбор запоминается в cookie, пока вариант существует и получает трафик.
+func pickVariant(ctx *ginext.Context, short string, variants []Variant, v visit) *Variant {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	cookie := variantCookiePrefix + short
+	if name, err := ctx.Cookie(cookie); err == nil {
+		for i := range variants {
+			if variants[i].Name == name && variants[i].Weight > 0 {
+				return &variants[i]
+			}
+		}
+	}
+
+	total := 0
+	for _, variant := range variants {
+		total += variant.Weight
+	}
+	if total == 0 {
+		return nil
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(short + "|" + v.IP + "|" + v.UA))
+	bucket := int(h.Sum64() % uint64(total))
+
+	for i := range variants {
+		if bucket < variants[i].Weight {
+			ctx.SetCookie(cookie, variants[i].Name, variantCookieMaxAge, "/", "", false, true)
+			return &variants[i]
+		}
+		bucket -= variants[i].Weight
+	}
+	return nil
+}
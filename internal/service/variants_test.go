@@ -0,0 +1,126 @@
+package service
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newVariantContext(cookie *http.Cookie) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/abc", nil)
+	if cookie != nil {
+		ctx.Request.AddCookie(cookie)
+	}
+	return ctx, w
+}
+
+func TestCheckVariants(t *testing.T) {
+	tests := []struct {
+		name     string
+		variants []Variant
+		wantErr  bool
+	}{
+		{"no variants", nil, false},
+		{"valid", []Variant{{Name: "a", Weight: 1}, {Name: "b", Weight: 0}}, false},
+		{"duplicate name", []Variant{{Name: "a", Weight: 1}, {Name: "a", Weight: 1}}, true},
+		{"no traffic", []Variant{{Name: "a"}, {Name: "b"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkVariants(tt.variants); (err != nil) != tt.wantErr {
+				t.Fatalf("checkVariants() = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPickVariantSticky(t *testing.T) {
+	variants := []Variant{{Name: "a", Weight: 50}, {Name: "b", Weight: 50}}
+	v := visit{IP: "203.0.113.7", UA: uaWindows}
+
+	ctx, w := newVariantContext(nil)
+	first := pickVariant(ctx, "abc", variants, v)
+	if first == nil {
+		t.Fatal("pickVariant() = nil")
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != variantCookiePrefix+"abc" || cookies[0].Value != first.Name {
+		t.Fatalf("choice is not remembered in a cookie: %v", cookies)
+	}
+
+	// без cookie тот же посетитель попадает в тот же вариант
+	for i := 0; i < 10; i++ {
+		ctx, _ := newVariantContext(nil)
+		if got := pickVariant(ctx, "abc", variants, v); got == nil || got.Name != first.Name {
+			t.Fatalf("repeat visit got %v, want %s", got, first.Name)
+		}
+	}
+}
+
+func TestPickVariantCookie(t *testing.T) {
+	variants := []Variant{{Name: "a", Weight: 1}, {Name: "b", Weight: 1}, {Name: "off", Weight: 0}}
+	v := visit{IP: "203.0.113.7", UA: uaWindows}
+
+	tests := []struct {
+		name   string
+		cookie string
+		want   []string // допустимые варианты
+	}{
+		{"cookie wins over hash", "b", []string{"b"}},
+		{"cookie of a disabled variant is ignored", "off", []string{"a", "b"}},
+		{"cookie of a removed variant is ignored", "gone", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := newVariantContext(&http.Cookie{Name: variantCookiePrefix + "abc", Value: tt.cookie})
+			got := pickVariant(ctx, "abc", variants, v)
+			if got == nil {
+				t.Fatal("pickVariant() = nil")
+			}
+			for _, name := range tt.want {
+				if got.Name == name {
+					return
+				}
+			}
+			t.Fatalf("pickVariant() = %s, want one of %v", got.Name, tt.want)
+		})
+	}
+}
+
+func TestPickVariantWeights(t *testing.T) {
+	variants := []Variant{{Name: "a", Weight: 75}, {Name: "b", Weight: 25}, {Name: "off", Weight: 0}}
+
+	counts := map[string]int{}
+	const visitors = 4000
+	for i := 0; i < visitors; i++ {
+		ctx, _ := newVariantContext(nil)
+		v := visit{IP: fmt.Sprintf("198.51.%d.%d", i/256, i%256), UA: uaWindows}
+		got := pickVariant(ctx, "abc", variants, v)
+		if got == nil {
+			t.Fatal("pickVariant() = nil")
+		}
+		counts[got.Name]++
+	}
+
+	if counts["off"] != 0 {
+		t.Fatalf("variant with zero weight got %d visitors", counts["off"])
+	}
+	share := float64(counts["a"]) / visitors
+	if share < 0.70 || share > 0.80 {
+		t.Fatalf("variant a got %.2f of traffic, want about 0.75 (%v)", share, counts)
+	}
+}
+
+func TestPickVariantNoTraffic(t *testing.T) {
+	ctx, _ := newVariantContext(nil)
+	if got := pickVariant(ctx, "abc", []Variant{{Name: "a"}}, visit{}); got != nil {
+		t.Fatalf("pickVariant() = %v, want nil", got)
+	}
+	if got := pickVariant(ctx, "abc", nil, visit{}); got != nil {
+		t.Fatalf("pickVariant(nil) = %v, want nil", got)
+	}
+}
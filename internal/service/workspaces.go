@@ -0,0 +1,49 @@
+package service
+
+import (
+	"github.com/wb-go/wbf/ginext"
+	"secondOne/internal/dto"
+	"secondOne/pkg/validator"
+)
+
+func (s *service) CreateWorkspace(ctx *ginext.Context) {
+	var req struct {
+		Name string `json:"name" validate:"required,max=100"`
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		s.log.Error().Msgf("Invalid request body: %v", err)
+		dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+		return
+	}
+
+	if err := validator.Validate(ctx.Request.Context(), req); err != nil {
+		dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
+		return
+	}
+
+	workspace, err := s.repo.CreateWorkspace(ctx.Request.Context(), req.Name)
+	if err != nil {
+		s.log.Error().Msgf("Failed to create workspace: %v", err)
+		dto.InternalServerError(ctx)
+		return
+	}
+
+	dto.SuccessCreatedResponse(ctx, toServiceWorkspace(*workspace))
+}
+
+func (s *service) ListWorkspaces(ctx *ginext.Context) {
+	workspaces, err := s.repo.ListWorkspaces(ctx.Request.Context())
+	if err != nil {
+		s.log.Error().Msgf("Failed to list workspaces: %v", err)
+		dto.InternalServerError(ctx)
+		return
+	}
+
+	result := make([]Workspace, 0, len(workspaces))
+	for _, w := range workspaces {
+		result = append(result, toServiceWorkspace(w))
+	}
+
+	dto.SuccessResponse(ctx, result)
+}
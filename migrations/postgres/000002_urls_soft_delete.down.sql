@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_urls_deleted_at;
+
+ALTER TABLE IF EXISTS urls DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,4 @@
+-- Мягкое удаление ссылок: строка и её клики остаются для отчётов
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP; -- может быть NULL
+
+CREATE INDEX IF NOT EXISTS idx_urls_deleted_at ON urls(deleted_at);
@@ -0,0 +1,10 @@
+-- API-ключи: в БД хранится только SHA-256 от ключа
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,           -- начало ключа, чтобы опознать его в списке
+    key_hash CHAR(64) UNIQUE NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP,                -- может быть NULL
+    revoked_at TIMESTAMP                   -- может быть NULL
+    );
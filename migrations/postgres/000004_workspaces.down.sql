@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_api_keys_workspace;
+DROP INDEX IF EXISTS idx_urls_workspace;
+
+ALTER TABLE IF EXISTS urls DROP COLUMN IF EXISTS api_key_id;
+ALTER TABLE IF EXISTS urls DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE IF EXISTS api_keys DROP COLUMN IF EXISTS workspace_id;
+
+DROP TABLE IF EXISTS workspaces;
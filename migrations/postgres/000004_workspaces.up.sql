@@ -0,0 +1,24 @@
+-- Рабочие пространства: ссылки и ключи принадлежат workspace
+CREATE TABLE IF NOT EXISTS workspaces (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+-- Существующие ключи и ссылки переносим в workspace по умолчанию
+INSERT INTO workspaces (name)
+SELECT 'default'
+WHERE NOT EXISTS (SELECT 1 FROM workspaces);
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS workspace_id BIGINT REFERENCES workspaces(id);
+UPDATE api_keys SET workspace_id = (SELECT MIN(id) FROM workspaces) WHERE workspace_id IS NULL;
+ALTER TABLE api_keys ALTER COLUMN workspace_id SET NOT NULL;
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS workspace_id BIGINT REFERENCES workspaces(id);
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS api_key_id BIGINT REFERENCES api_keys(id); -- кто создал, может быть NULL
+UPDATE urls SET workspace_id = (SELECT MIN(id) FROM workspaces) WHERE workspace_id IS NULL;
+ALTER TABLE urls ALTER COLUMN workspace_id SET NOT NULL;
+
+-- Алиасы по-прежнему уникальны глобально (один домен на сервис)
+CREATE INDEX IF NOT EXISTS idx_urls_workspace ON urls(workspace_id, id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_workspace ON api_keys(workspace_id);
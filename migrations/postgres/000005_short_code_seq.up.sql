@@ -0,0 +1,2 @@
+-- Источник номеров для стратегий коротких кодов sequence и obfuscated
+CREATE SEQUENCE IF NOT EXISTS short_code_seq START WITH 1 MINVALUE 0;
@@ -0,0 +1,2 @@
+-- Поиск существующей ссылки на тот же адрес для режима дедупликации
+CREATE INDEX IF NOT EXISTS idx_urls_original_hash ON urls USING hash (original);
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS urls DROP COLUMN IF EXISTS redirect_code;
@@ -0,0 +1,3 @@
+-- Код ответа при редиректе; NULL — используется значение shortener.redirect_code из конфига
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS redirect_code SMALLINT
+    CHECK (redirect_code IN (301, 302, 307, 308));
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_clicks_utm_campaign;
+
+ALTER TABLE IF EXISTS clicks DROP COLUMN IF EXISTS utm_campaign;
+
+ALTER TABLE IF EXISTS urls DROP COLUMN IF EXISTS utm;
+ALTER TABLE IF EXISTS urls DROP COLUMN IF EXISTS query_forwarding;
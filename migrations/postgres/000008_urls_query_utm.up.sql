@@ -0,0 +1,12 @@
+-- Проброс query-параметров перехода в адрес назначения:
+-- off — не пробрасывать, merge — добавлять только отсутствующие, override — параметры перехода важнее
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS query_forwarding VARCHAR(10) NOT NULL DEFAULT 'off'
+    CHECK (query_forwarding IN ('off', 'merge', 'override'));
+
+-- UTM-метки ссылки: {"source": "...", "medium": "...", "campaign": "...", "term": "...", "content": "..."}
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS utm JSONB; -- может быть NULL
+
+-- utm_campaign, с которым посетитель ушёл на адрес назначения
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS utm_campaign VARCHAR(100); -- может быть NULL
+
+CREATE INDEX IF NOT EXISTS idx_clicks_utm_campaign ON clicks(utm_campaign);
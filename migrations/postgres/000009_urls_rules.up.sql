@@ -0,0 +1,3 @@
+-- Правила таргетинга: упорядоченный массив [{"os": "ios", "device": "mobile", "browser": "", "url": "..."}],
+-- при редиректе выбирается первое подходящее правило, иначе используется original
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS rules JSONB; -- может быть NULL
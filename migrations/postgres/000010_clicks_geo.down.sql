@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_clicks_country;
+
+ALTER TABLE IF EXISTS clicks DROP COLUMN IF EXISTS city;
+ALTER TABLE IF EXISTS clicks DROP COLUMN IF EXISTS region;
+ALTER TABLE IF EXISTS clicks DROP COLUMN IF EXISTS country;
@@ -0,0 +1,6 @@
+-- Местоположение посетителя по локальной базе GeoIP
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS country VARCHAR(2);   -- может быть NULL, ISO 3166-1 alpha-2
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS region VARCHAR(100);  -- может быть NULL
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS city VARCHAR(100);    -- может быть NULL
+
+CREATE INDEX IF NOT EXISTS idx_clicks_country ON clicks(country);
@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS clicks DROP COLUMN IF EXISTS variant;
+
+ALTER TABLE IF EXISTS urls DROP COLUMN IF EXISTS variants;
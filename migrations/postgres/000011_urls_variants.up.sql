@@ -0,0 +1,5 @@
+-- A/B-варианты адреса назначения: [{"name": "a", "url": "...", "weight": 50}]
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS variants JSONB; -- может быть NULL
+
+-- вариант, на который ушёл посетитель
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS variant VARCHAR(50); -- может быть NULL
@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS urls DROP COLUMN IF EXISTS click_count;
+ALTER TABLE IF EXISTS urls DROP COLUMN IF EXISTS max_clicks;
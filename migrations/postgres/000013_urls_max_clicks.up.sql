@@ -0,0 +1,4 @@
+-- Лимит переходов: после max_clicks успешных редиректов ссылка перестаёт работать.
+-- click_count увеличивается только у ссылок с лимитом
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS max_clicks INTEGER CHECK (max_clicks > 0); -- может быть NULL
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS click_count INTEGER NOT NULL DEFAULT 0;
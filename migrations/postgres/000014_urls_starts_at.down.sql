@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS urls DROP COLUMN IF EXISTS prelaunch_url;
+ALTER TABLE IF EXISTS urls DROP COLUMN IF EXISTS prelaunch_mode;
+ALTER TABLE IF EXISTS urls DROP COLUMN IF EXISTS starts_at;
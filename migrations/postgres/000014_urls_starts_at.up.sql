@@ -0,0 +1,7 @@
+-- Отложенный запуск: до starts_at ссылка ведёт себя по prelaunch_mode
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS starts_at TIMESTAMP; -- может быть NULL
+-- not_found, coming_soon или fallback; NULL — значение shortener.prelaunch из конфига
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS prelaunch_mode VARCHAR(20)
+    CHECK (prelaunch_mode IN ('not_found', 'coming_soon', 'fallback'));
+-- адрес для режима fallback
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS prelaunch_url TEXT; -- может быть NULL
@@ -0,0 +1,2 @@
+-- Куда вести посетителя после expires_at; NULL — shortener.expired_url из конфига или 410
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS expired_url TEXT;
@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS urls DROP COLUMN IF EXISTS interstitial;
+ALTER TABLE IF EXISTS urls DROP COLUMN IF EXISTS title;
@@ -0,0 +1,4 @@
+-- Заголовок ссылки для страницы предпросмотра
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS title VARCHAR(200); -- может быть NULL
+-- Показывать страницу-предупреждение перед уходом на адрес назначения
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS interstitial BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_clicks_short_source;
+
+ALTER TABLE IF EXISTS clicks DROP COLUMN IF EXISTS source;
@@ -0,0 +1,4 @@
+-- откуда пришёл переход: link — обычная ссылка, qr — сканирование QR-кода (?src=qr)
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS source VARCHAR(20) NOT NULL DEFAULT 'link';
+
+CREATE INDEX IF NOT EXISTS idx_clicks_short_source ON clicks(short, source);
@@ -0,0 +1,2 @@
+-- Адреса мобильного приложения: {"ios": "myapp://product/42", "ios_store": "...", "android": "...", "android_store": "..."}
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS deep_link JSONB; -- может быть NULL
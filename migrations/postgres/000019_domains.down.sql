@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_urls_domain_alias;
+-- не сработает, если один алиас уже занят на нескольких доменах
+ALTER TABLE urls ADD CONSTRAINT urls_custom_alias_key UNIQUE (custom_alias);
+
+ALTER TABLE IF EXISTS urls DROP COLUMN IF EXISTS domain_id;
+
+DROP TABLE IF EXISTS domains;
@@ -0,0 +1,15 @@
+-- Брендированные короткие домены: https://go.acme.io/abc
+CREATE TABLE IF NOT EXISTS domains (
+    id BIGSERIAL PRIMARY KEY,
+    host VARCHAR(253) UNIQUE NOT NULL,          -- в нижнем регистре, без порта
+    workspace_id BIGINT REFERENCES workspaces(id), -- NULL — домен доступен всем workspace
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+-- NULL — домен сервиса по умолчанию
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS domain_id BIGINT REFERENCES domains(id);
+
+-- Алиасы уникальны в пределах домена. short остаётся глобально уникальным:
+-- по нему хранятся клики, а ссылки с алиасом на брендированном домене получают сгенерированный short.
+ALTER TABLE urls DROP CONSTRAINT IF EXISTS urls_custom_alias_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_domain_alias ON urls (COALESCE(domain_id, 0), custom_alias);
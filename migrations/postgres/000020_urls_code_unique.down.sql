@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_urls_domain_short;
+
+DROP TRIGGER IF EXISTS trg_urls_check_code ON urls;
+
+DROP FUNCTION IF EXISTS urls_check_code();
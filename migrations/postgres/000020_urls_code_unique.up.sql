@@ -0,0 +1,35 @@
+-- Код на домене — short или алиас — принадлежит одной ссылке. Уникальные индексы покрывают
+-- каждую колонку по отдельности, поэтому пересечение short и алиаса проверяет триггер.
+-- Блокировка по коду домена сериализует только вставки с тем же кодом.
+CREATE OR REPLACE FUNCTION urls_check_code() RETURNS trigger AS $$
+DECLARE
+    code TEXT;
+BEGIN
+    FOR code IN
+        SELECT DISTINCT c FROM unnest(ARRAY[NEW.short, NEW.custom_alias]) AS c WHERE c IS NOT NULL ORDER BY c
+    LOOP
+        PERFORM pg_advisory_xact_lock(hashtextextended(COALESCE(NEW.domain_id, 0) || ':' || code, 0));
+        IF EXISTS (
+            SELECT 1 FROM urls
+            WHERE id IS DISTINCT FROM NEW.id AND COALESCE(domain_id, 0) = COALESCE(NEW.domain_id, 0)
+              AND (short = code OR custom_alias = code)
+        ) THEN
+            IF TG_OP = 'INSERT' THEN
+                -- как ON CONFLICT DO NOTHING: строка пропускается, CreateUrl вернёт ErrUrlConflict
+                -- и транзакция пакетного создания останется живой для повторной попытки
+                RETURN NULL;
+            END IF;
+            RAISE EXCEPTION 'code % is already taken on domain %', code, COALESCE(NEW.domain_id, 0)
+                USING ERRCODE = 'unique_violation';
+        END IF;
+    END LOOP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_urls_check_code ON urls;
+CREATE TRIGGER trg_urls_check_code
+    BEFORE INSERT OR UPDATE OF short, custom_alias, domain_id ON urls
+    FOR EACH ROW EXECUTE FUNCTION urls_check_code();
+
+CREATE INDEX IF NOT EXISTS idx_urls_domain_short ON urls (COALESCE(domain_id, 0), short);
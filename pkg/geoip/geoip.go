@@ -0,0 +1,123 @@
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/rs/zerolog"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Location — результат поиска по IP; пустые поля означают, что данных нет
+type Location struct {
+	Country string // ISO 3166-1 alpha-2, в верхнем регистре
+	Region  string
+	City    string
+}
+
+// record — подмножество схемы GeoIP2/GeoLite2 City и Country
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// Resolver определяет местоположение по IP из локального .mmdb-файла.
+// Файл читается в память целиком, поэтому подмена базы при перезагрузке
+// не мешает уже идущим запросам. Без загруженной базы Lookup возвращает пустой Location.
+type Resolver struct {
+	path   string
+	log    *zerolog.Logger
+	reader atomic.Pointer[maxminddb.Reader]
+
+	modTime time.Time
+	size    int64
+}
+
+// New создаёт Resolver и пытается загрузить базу. Отсутствие файла не ошибка:
+// база подхватится в Watch, когда появится.
+func New(path string, log *zerolog.Logger) *Resolver {
+	r := &Resolver{path: path, log: log}
+	if err := r.Reload(); err != nil {
+		log.Warn().Msgf("GeoIP database is not loaded: %v", err)
+	}
+	return r
+}
+
+// Reload перечитывает базу с диска и атомарно подменяет текущую
+func (r *Resolver) Reload() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat GeoIP database: %w", err)
+	}
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to read GeoIP database: %w", err)
+	}
+	reader, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse GeoIP database: %w", err)
+	}
+
+	r.reader.Store(reader)
+	r.modTime, r.size = info.ModTime(), info.Size()
+	r.log.Info().Msgf("GeoIP database loaded: %s (%s, built %s)",
+		r.path, reader.Metadata.DatabaseType, time.Unix(int64(reader.Metadata.BuildEpoch), 0).UTC().Format("2006-01-02"))
+	return nil
+}
+
+// Watch раз в interval проверяет время изменения и размер файла и перезагружает базу.
+// Блокируется до отмены ctx, запускается в отдельной горутине.
+func (r *Resolver) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(r.path)
+			if err != nil || (info.ModTime().Equal(r.modTime) && info.Size() == r.size) {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				// файл мог быть записан не до конца, попробуем на следующем тике
+				r.log.Warn().Msgf("Failed to reload GeoIP database: %v", err)
+			}
+		}
+	}
+}
+
+// Lookup возвращает местоположение IP; названия региона и города — на английском
+func (r *Resolver) Lookup(ip string) Location {
+	if r == nil {
+		return Location{}
+	}
+	reader := r.reader.Load()
+	parsed := net.ParseIP(ip)
+	if reader == nil || parsed == nil {
+		return Location{}
+	}
+
+	var rec record
+	if err := reader.Lookup(parsed, &rec); err != nil {
+		r.log.Warn().Msgf("GeoIP lookup failed for %s: %v", ip, err)
+		return Location{}
+	}
+
+	loc := Location{Country: rec.Country.ISOCode, City: rec.City.Names["en"]}
+	if len(rec.Subdivisions) > 0 {
+		loc.Region = rec.Subdivisions[0].Names["en"]
+	}
+	return loc
+}
@@ -0,0 +1,158 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/skip2/go-qrcode"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+)
+
+// Options — параметры отрисовки QR-кода
+type Options struct {
+	Size       int    // сторона изображения в пикселях
+	Margin     int    // ширина «тихой зоны» в модулях; стандарт рекомендует 4
+	Level      string // уровень коррекции ошибок: L, M, Q, H
+	Foreground color.NRGBA
+	Background color.NRGBA
+}
+
+// ErrTooSmall — в заданный размер не помещается ни одного пикселя на модуль
+var ErrTooSmall = errors.New("size is too small for this content")
+
+var levels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// ValidLevel сообщает, поддерживается ли уровень коррекции ошибок
+func ValidLevel(level string) bool {
+	_, ok := levels[level]
+	return ok
+}
+
+// ParseColor разбирает цвет вида RGB, RRGGBB или RRGGBBAA, "#" в начале необязателен
+func ParseColor(s string) (color.NRGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) == 6 {
+		s += "ff"
+	}
+	if len(s) != 8 {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// bitmap кодирует content и возвращает матрицу модулей вместе с тихой зоной
+func bitmap(content string, opt Options) ([][]bool, error) {
+	level, ok := levels[opt.Level]
+	if !ok {
+		return nil, fmt.Errorf("unsupported error correction level %q", opt.Level)
+	}
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	code.DisableBorder = true
+	modules := code.Bitmap()
+
+	n := len(modules) + 2*opt.Margin
+	out := make([][]bool, n)
+	for y := range out {
+		out[y] = make([]bool, n)
+	}
+	for y, row := range modules {
+		copy(out[y+opt.Margin][opt.Margin:], row)
+	}
+	return out, nil
+}
+
+// PNG рисует QR-код размером Size×Size. Модули целочисленной ширины,
+// остаток делится поровну по краям и заливается фоном.
+func PNG(content string, opt Options) ([]byte, error) {
+	modules, err := bitmap(content, opt)
+	if err != nil {
+		return nil, err
+	}
+	n := len(modules)
+	scale := opt.Size / n
+	if scale < 1 {
+		return nil, ErrTooSmall
+	}
+	offset := (opt.Size - n*scale) / 2
+
+	img := image.NewPaletted(image.Rect(0, 0, opt.Size, opt.Size), color.Palette{opt.Background, opt.Foreground})
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				start := img.PixOffset(offset+x*scale, offset+y*scale+dy)
+				for dx := 0; dx < scale; dx++ {
+					img.Pix[start+dx] = 1
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG рисует QR-код векторно: один модуль — одна единица viewBox,
+// тёмные модули собираются в горизонтальные полосы одного path
+func SVG(content string, opt Options) ([]byte, error) {
+	modules, err := bitmap(content, opt)
+	if err != nil {
+		return nil, err
+	}
+	n := len(modules)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opt.Size, opt.Size, n, n)
+	if opt.Background.A > 0 {
+		fmt.Fprintf(&buf, `<rect width="%d" height="%d"%s/>`, n, n, svgFill(opt.Background))
+	}
+
+	buf.WriteString(`<path d="`)
+	for y, row := range modules {
+		for x := 0; x < n; x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < n && row[x] {
+				x++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	fmt.Fprintf(&buf, `"%s/></svg>`, svgFill(opt.Foreground))
+	return buf.Bytes(), nil
+}
+
+func svgFill(c color.NRGBA) string {
+	fill := fmt.Sprintf(` fill="#%02x%02x%02x"`, c.R, c.G, c.B)
+	if c.A < 255 {
+		fill += fmt.Sprintf(` fill-opacity="%.3f"`, float64(c.A)/255)
+	}
+	return fill
+}
@@ -0,0 +1,114 @@
+package screening
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// blocklist — запрещённые домены. Строка без "*" блокирует домен вместе с поддоменами,
+// строка со "*" — шаблон на весь хост: *.example.com — только поддомены, paypa1-*.com — по маске.
+type blocklist struct {
+	domains  map[string]bool
+	patterns []string
+}
+
+func parseBlocklist(data []byte) (*blocklist, error) {
+	list := &blocklist{domains: map[string]bool{}}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		entry := scanner.Text()
+		if i := strings.IndexByte(entry, '#'); i >= 0 {
+			entry = entry[:i]
+		}
+		entry = normalizeHost(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+
+		if strings.Contains(entry, "*") {
+			if _, err := path.Match(entry, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q on line %d: %w", entry, line, err)
+			}
+			list.patterns = append(list.patterns, entry)
+			continue
+		}
+		list.domains[entry] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// match возвращает запись списка, под которую попал хост
+func (b *blocklist) match(host string) (string, bool) {
+	for domain := host; domain != ""; {
+		if b.domains[domain] {
+			return domain, true
+		}
+		i := strings.IndexByte(domain, '.')
+		if i < 0 {
+			break
+		}
+		domain = domain[i+1:]
+	}
+	for _, pattern := range b.patterns {
+		if ok, _ := path.Match(pattern, host); ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// Reload перечитывает список доменов с диска и атомарно подменяет текущий
+func (s *Screener) Reload() error {
+	info, err := os.Stat(s.cfg.BlocklistPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat URL blocklist: %w", err)
+	}
+	data, err := os.ReadFile(s.cfg.BlocklistPath)
+	if err != nil {
+		return fmt.Errorf("failed to read URL blocklist: %w", err)
+	}
+	list, err := parseBlocklist(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse URL blocklist: %w", err)
+	}
+
+	s.blocklist.Store(list)
+	s.modTime, s.size = info.ModTime(), info.Size()
+	s.log.Info().Msgf("URL blocklist loaded: %s (%d domains, %d patterns)",
+		s.cfg.BlocklistPath, len(list.domains), len(list.patterns))
+	return nil
+}
+
+// Watch раз в interval проверяет время изменения и размер файла и перезагружает список.
+// Блокируется до отмены ctx, запускается в отдельной горутине.
+func (s *Screener) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(s.cfg.BlocklistPath)
+			if err != nil || (info.ModTime().Equal(s.modTime) && info.Size() == s.size) {
+				continue
+			}
+			if err := s.Reload(); err != nil {
+				// при ошибке остаётся прежний список, попробуем на следующем тике
+				s.log.Warn().Msgf("Failed to reload URL blocklist: %v", err)
+			}
+		}
+	}
+}
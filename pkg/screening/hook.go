@@ -0,0 +1,68 @@
+package screening
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// HTTPChecker спрашивает внешний сервис, опасен ли адрес: POST {"url": "..."} на Endpoint,
+// в ответ {"unsafe": true, "threat": "phishing"}. Так к сервису подключается
+// локальная замена Safe Browsing или собственная служба репутации доменов.
+type HTTPChecker struct {
+	Endpoint string
+	Client   *http.Client
+}
+
+// NewHTTPChecker создаёт HTTPChecker с таймаутом на запрос
+func NewHTTPChecker(endpoint string, timeout time.Duration) *HTTPChecker {
+	return &HTTPChecker{Endpoint: endpoint, Client: &http.Client{Timeout: timeout}}
+}
+
+type hookRequest struct {
+	URL string `json:"url"`
+}
+
+type hookResponse struct {
+	Unsafe bool   `json:"unsafe"`
+	Threat string `json:"threat,omitempty"`
+}
+
+func (c *HTTPChecker) Check(ctx context.Context, u *url.URL) error {
+	body, err := json.Marshal(hookRequest{URL: u.String()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal URL check request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create URL check request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call URL check hook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("URL check hook returned status %d", resp.StatusCode)
+	}
+
+	var verdict hookResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&verdict); err != nil {
+		return fmt.Errorf("failed to decode URL check response: %w", err)
+	}
+	if !verdict.Unsafe {
+		return nil
+	}
+	threat := verdict.Threat
+	if threat == "" {
+		threat = "unsafe"
+	}
+	return &Rejection{Reason: ReasonUnsafe, Detail: fmt.Sprintf("%s is flagged as %s", u.Host, threat)}
+}
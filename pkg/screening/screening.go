@@ -0,0 +1,193 @@
+package screening
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Причины отказа
+const (
+	ReasonScheme    = "scheme"
+	ReasonPrivate   = "private_address"
+	ReasonBlocklist = "blocklisted"
+	ReasonUnsafe    = "unsafe"
+)
+
+// Rejection — адрес назначения не прошёл проверку
+type Rejection struct {
+	Reason string // одна из Reason*
+	Detail string // пояснение для клиента
+}
+
+func (r *Rejection) Error() string {
+	return fmt.Sprintf("destination rejected (%s): %s", r.Reason, r.Detail)
+}
+
+// Checker — внешняя проверка адреса, например локальная замена Safe Browsing.
+// Возвращает *Rejection для опасного адреса; прочие ошибки считаются сбоем проверки,
+// и адрес пропускается, чтобы недоступность сервиса не останавливала создание ссылок.
+type Checker interface {
+	Check(ctx context.Context, u *url.URL) error
+}
+
+// Config — настройки проверки
+type Config struct {
+	Schemes        []string // разрешённые схемы; пусто — http и https
+	AllowPrivate   bool     // не проверять адреса локальных сетей
+	ResolveHosts   bool     // проверять ещё и IP, в которые резолвится хост
+	ResolveTimeout time.Duration
+	BlocklistPath  string // пусто — без списка доменов
+}
+
+// Screener проверяет адреса назначения: схема, локальные сети, список запрещённых
+// доменов и подключённые Checker по порядку. Список доменов читается в память
+// и подменяется атомарно при перезагрузке.
+type Screener struct {
+	cfg      Config
+	schemes  map[string]bool
+	checkers []Checker
+	log      *zerolog.Logger
+
+	blocklist atomic.Pointer[blocklist]
+	modTime   time.Time
+	size      int64
+}
+
+// New создаёт Screener и загружает список доменов. Отсутствие файла не ошибка:
+// список подхватится в Watch, когда появится.
+func New(cfg Config, log *zerolog.Logger, checkers ...Checker) *Screener {
+	if len(cfg.Schemes) == 0 {
+		cfg.Schemes = []string{"http", "https"}
+	}
+	if cfg.ResolveTimeout <= 0 {
+		cfg.ResolveTimeout = 2 * time.Second
+	}
+
+	s := &Screener{cfg: cfg, schemes: map[string]bool{}, checkers: checkers, log: log}
+	for _, scheme := range cfg.Schemes {
+		s.schemes[strings.ToLower(strings.TrimSpace(scheme))] = true
+	}
+	s.blocklist.Store(&blocklist{})
+	if cfg.BlocklistPath != "" {
+		if err := s.Reload(); err != nil {
+			log.Warn().Msgf("URL blocklist is not loaded: %v", err)
+		}
+	}
+	return s
+}
+
+// Screen возвращает *Rejection, если по адресу нельзя создавать ссылку, иначе nil
+func (s *Screener) Screen(ctx context.Context, rawURL string) *Rejection {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return &Rejection{Reason: ReasonScheme, Detail: "URL cannot be parsed"}
+	}
+	if !s.schemes[strings.ToLower(u.Scheme)] {
+		return &Rejection{Reason: ReasonScheme, Detail: fmt.Sprintf("scheme %q is not allowed", u.Scheme)}
+	}
+
+	host := normalizeHost(u.Hostname())
+	if host == "" {
+		return &Rejection{Reason: ReasonScheme, Detail: "URL has no host"}
+	}
+
+	if !s.cfg.AllowPrivate {
+		if rej := s.checkPrivate(ctx, host); rej != nil {
+			return rej
+		}
+	}
+
+	if pattern, ok := s.blocklist.Load().match(host); ok {
+		return &Rejection{Reason: ReasonBlocklist, Detail: fmt.Sprintf("domain %s is blocked (%s)", host, pattern)}
+	}
+
+	for _, checker := range s.checkers {
+		err := checker.Check(ctx, u)
+		if err == nil {
+			continue
+		}
+		if rej, ok := err.(*Rejection); ok {
+			return rej
+		}
+		s.log.Warn().Msgf("URL check failed, skipping it: %v", err)
+	}
+	return nil
+}
+
+// numericHost — хост из чисел, который браузер поймёт как IPv4: 2130706433, 0x7f.1, 127.1
+var numericHost = regexp.MustCompile(`^(0x[0-9a-f]*|[0-9]+)(\.(0x[0-9a-f]*|[0-9]+))*$`)
+
+// локальные имена, которые не резолвятся публично
+var localSuffixes = []string{"localhost", ".localhost", ".local", ".internal", ".home.arpa"}
+
+func (s *Screener) checkPrivate(ctx context.Context, host string) *Rejection {
+	if ip := net.ParseIP(strings.SplitN(host, "%", 2)[0]); ip != nil {
+		if isPrivateIP(ip) {
+			return &Rejection{Reason: ReasonPrivate, Detail: fmt.Sprintf("%s is a private or reserved address", host)}
+		}
+		return nil
+	}
+	if numericHost.MatchString(host) {
+		return &Rejection{Reason: ReasonPrivate, Detail: fmt.Sprintf("numeric host %s is not allowed, use a dotted IP or a domain", host)}
+	}
+	for _, suffix := range localSuffixes {
+		if host == strings.TrimPrefix(suffix, ".") || strings.HasSuffix(host, suffix) {
+			return &Rejection{Reason: ReasonPrivate, Detail: fmt.Sprintf("%s is a local host name", host)}
+		}
+	}
+
+	if !s.cfg.ResolveHosts {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.ResolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		// домен может быть ещё не заведён — это не повод отказывать
+		return nil
+	}
+	for _, addr := range addrs {
+		if isPrivateIP(addr.IP) {
+			return &Rejection{Reason: ReasonPrivate, Detail: fmt.Sprintf("%s resolves to a private address", host)}
+		}
+	}
+	return nil
+}
+
+// сети, не покрытые методами net.IP: CGNAT и «этот хост»
+var reservedNets = []*net.IPNet{
+	mustCIDR("100.64.0.0/10"),
+	mustCIDR("0.0.0.0/8"),
+}
+
+func isPrivateIP(ip net.IP) bool {
+	if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, n := range reservedNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func mustCIDR(cidr string) *net.IPNet {
+	_, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
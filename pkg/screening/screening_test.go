@@ -0,0 +1,261 @@
+package screening
+
+import (
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newScreener(t *testing.T, cfg Config, checkers ...Checker) *Screener {
+	t.Helper()
+	log := zerolog.Nop()
+	return New(cfg, &log, checkers...)
+}
+
+func writeBlocklist(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func reason(rej *Rejection) string {
+	if rej == nil {
+		return ""
+	}
+	return rej.Reason
+}
+
+func TestIsPrivateIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"127.0.0.1", true},
+		{"169.254.169.254", true},
+		{"100.64.1.1", true},
+		{"0.0.0.0", true},
+		{"0.1.2.3", true},
+		{"224.0.0.1", true},
+		{"::1", true},
+		{"fe80::1", true},
+		{"fc00::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"8.8.8.8", false},
+		{"100.128.0.1", false},
+		{"172.32.0.1", false},
+		{"2001:4860:4860::8888", false},
+	}
+	for _, tt := range tests {
+		if got := isPrivateIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isPrivateIP(%s) = %t, want %t", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestScreenSchemeAndPrivate(t *testing.T) {
+	s := newScreener(t, Config{})
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/path?q=1", ""},
+		{"http://8.8.8.8/", ""},
+		{"HTTPS://Example.COM", ""},
+		{"javascript:alert(1)", ReasonScheme},
+		{"data:text/html,hi", ReasonScheme},
+		{"ftp://example.com/file", ReasonScheme},
+		{"http:///no-host", ReasonScheme},
+		{"http://127.0.0.1:8080/admin", ReasonPrivate},
+		{"http://[::1]/", ReasonPrivate},
+		{"http://[fe80::1%25eth0]/", ReasonPrivate},
+		{"http://169.254.169.254/latest/meta-data", ReasonPrivate},
+		{"http://localhost/", ReasonPrivate},
+		{"http://LOCALHOST./", ReasonPrivate},
+		{"http://api.localhost/", ReasonPrivate},
+		{"http://printer.local/", ReasonPrivate},
+		{"http://svc.internal/", ReasonPrivate},
+		{"http://router.home.arpa/", ReasonPrivate},
+		{"http://notlocalhost.com/", ""},
+	}
+	for _, tt := range tests {
+		if got := reason(s.Screen(context.Background(), tt.url)); got != tt.want {
+			t.Errorf("Screen(%q) reason = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestScreenNumericHost(t *testing.T) {
+	s := newScreener(t, Config{})
+
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"2130706433", ReasonPrivate}, // 127.0.0.1 одним числом
+		{"0x7f000001", ReasonPrivate},
+		{"0x7f.1", ReasonPrivate},
+		{"127.1", ReasonPrivate},
+		{"017700000001", ReasonPrivate}, // восьмеричная запись
+		{"1.2.3.4.nip.io", ""},
+		{"0xcafe.com", ""},
+		{"123.example", ""},
+	}
+	for _, tt := range tests {
+		if got := reason(s.Screen(context.Background(), "http://"+tt.host+"/")); got != tt.want {
+			t.Errorf("host %q: reason = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestScreenAllowPrivateAndSchemes(t *testing.T) {
+	s := newScreener(t, Config{Schemes: []string{"https"}, AllowPrivate: true})
+
+	if rej := s.Screen(context.Background(), "https://10.0.0.1/"); rej != nil {
+		t.Fatalf("private address rejected with AllowPrivate: %v", rej)
+	}
+	if got := reason(s.Screen(context.Background(), "http://example.com/")); got != ReasonScheme {
+		t.Fatalf("http is not in the allowlist, got reason %q", got)
+	}
+}
+
+func TestBlocklistMatch(t *testing.T) {
+	list, err := parseBlocklist([]byte(`
+# фишинг
+evil.com
+*.phish.net          # только поддомены
+paypa1-*.com
+Trailing.Dot.Org.
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"evil.com", "evil.com"},
+		{"login.evil.com", "evil.com"},
+		{"a.b.evil.com", "evil.com"},
+		{"notevil.com", ""},
+		{"evil.com.example.org", ""},
+		{"phish.net", ""},
+		{"x.phish.net", "*.phish.net"},
+		{"a.b.phish.net", "*.phish.net"},
+		{"paypa1-login.com", "paypa1-*.com"},
+		{"paypal.com", ""},
+		{"trailing.dot.org", "trailing.dot.org"},
+	}
+	for _, tt := range tests {
+		got, ok := list.match(tt.host)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("match(%q) = %q, %t; want %q", tt.host, got, ok, tt.want)
+		}
+	}
+}
+
+func TestParseBlocklistInvalidPattern(t *testing.T) {
+	if _, err := parseBlocklist([]byte("ok.com\n*.bad[.com\n")); err == nil {
+		t.Fatal("parseBlocklist accepted a malformed pattern")
+	}
+}
+
+func TestScreenBlocklistReload(t *testing.T) {
+	path := writeBlocklist(t, "evil.com\n")
+	s := newScreener(t, Config{BlocklistPath: path})
+
+	if got := reason(s.Screen(context.Background(), "https://www.EVIL.com/login")); got != ReasonBlocklist {
+		t.Fatalf("blocklisted domain: reason %q", got)
+	}
+
+	if err := os.WriteFile(path, []byte("other.org\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if rej := s.Screen(context.Background(), "https://evil.com/"); rej != nil {
+		t.Fatalf("domain removed from the list is still rejected: %v", rej)
+	}
+	if got := reason(s.Screen(context.Background(), "https://other.org/")); got != ReasonBlocklist {
+		t.Fatalf("reloaded list is not applied: reason %q", got)
+	}
+
+	// битый файл не заменяет рабочий список
+	if err := os.WriteFile(path, []byte("*.bad[\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(); err == nil {
+		t.Fatal("Reload accepted a malformed list")
+	}
+	if got := reason(s.Screen(context.Background(), "https://other.org/")); got != ReasonBlocklist {
+		t.Fatalf("previous list is lost after a failed reload: reason %q", got)
+	}
+}
+
+func TestScreenWatch(t *testing.T) {
+	path := writeBlocklist(t, "evil.com\n")
+	s := newScreener(t, Config{BlocklistPath: path})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Watch(ctx, 10*time.Millisecond)
+
+	if err := os.WriteFile(path, []byte("evil.com\nworse.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for reason(s.Screen(context.Background(), "https://worse.com/")) != ReasonBlocklist {
+		if time.Now().After(deadline) {
+			t.Fatal("changed blocklist was not picked up by Watch")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+type checkerFunc func(ctx context.Context, u *url.URL) error
+
+func (f checkerFunc) Check(ctx context.Context, u *url.URL) error { return f(ctx, u) }
+
+func TestScreenCheckers(t *testing.T) {
+	var called []string
+	failing := checkerFunc(func(_ context.Context, u *url.URL) error {
+		called = append(called, "failing")
+		return errors.New("connection refused")
+	})
+	flagging := checkerFunc(func(_ context.Context, u *url.URL) error {
+		called = append(called, "flagging")
+		if u.Hostname() == "bad.example.org" {
+			return &Rejection{Reason: ReasonUnsafe, Detail: "malware"}
+		}
+		return nil
+	})
+	s := newScreener(t, Config{}, failing, flagging)
+
+	if rej := s.Screen(context.Background(), "https://good.example.org/"); rej != nil {
+		t.Fatalf("checker failure must not reject the URL: %v", rej)
+	}
+	if got := reason(s.Screen(context.Background(), "https://bad.example.org/")); got != ReasonUnsafe {
+		t.Fatalf("flagged URL: reason %q", got)
+	}
+
+	// до внешних проверок доходят только адреса, прошедшие локальные
+	called = nil
+	s.Screen(context.Background(), "http://127.0.0.1/")
+	if len(called) != 0 {
+		t.Fatalf("checkers called for a locally rejected URL: %v", called)
+	}
+}
@@ -0,0 +1,203 @@
+package shortcode
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	StrategyRandom     = "random"
+	StrategySequence   = "sequence"
+	StrategyObfuscated = "obfuscated"
+
+	DefaultAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	// MaxLength совпадает с размером колонки urls.short
+	MaxLength = 30
+)
+
+// Generator выдаёт кандидатов в короткие коды. Уникальность гарантирует БД:
+// при конфликте вызывающий повторяет Generate с увеличенным attempt.
+type Generator interface {
+	Generate(ctx context.Context, attempt int) (string, error)
+}
+
+// SequenceSource — источник монотонных номеров (sequence в Postgres)
+type SequenceSource interface {
+	NextShortSequence(ctx context.Context) (int64, error)
+}
+
+type Config struct {
+	Strategy    string
+	Length      int // минимальная длина кода
+	Alphabet    string
+	MaxAttempts int    // сколько кодов пробовать при конфликтах
+	GrowAfter   int    // после скольких конфликтов подряд random увеличивает длину
+	Salt        uint64 // смещение перестановки для obfuscated
+}
+
+func New(cfg Config, seq SequenceSource) (Generator, error) {
+	if cfg.Alphabet == "" {
+		cfg.Alphabet = DefaultAlphabet
+	}
+	if err := validateAlphabet(cfg.Alphabet); err != nil {
+		return nil, err
+	}
+	if cfg.Length < 1 || cfg.Length > MaxLength {
+		return nil, fmt.Errorf("short code length must be between 1 and %d", MaxLength)
+	}
+	if cfg.GrowAfter < 1 {
+		cfg.GrowAfter = 1
+	}
+
+	switch cfg.Strategy {
+	case "", StrategyRandom:
+		g := &randomGenerator{alphabet: cfg.Alphabet, growAfter: cfg.GrowAfter}
+		g.length.Store(int32(cfg.Length))
+		return g, nil
+	case StrategySequence, StrategyObfuscated:
+		if seq == nil {
+			return nil, fmt.Errorf("strategy %q requires a sequence source", cfg.Strategy)
+		}
+		return &sequenceGenerator{
+			seq:        seq,
+			alphabet:   cfg.Alphabet,
+			length:     cfg.Length,
+			obfuscated: cfg.Strategy == StrategyObfuscated,
+			salt:       cfg.Salt,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown short code strategy %q", cfg.Strategy)
+	}
+}
+
+func validateAlphabet(alphabet string) error {
+	if len(alphabet) < 2 {
+		return fmt.Errorf("short code alphabet must contain at least 2 characters")
+	}
+	seen := map[byte]bool{}
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if c > 127 || !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("-_", c) >= 0) {
+			return fmt.Errorf("short code alphabet contains unsafe character %q", c)
+		}
+		if seen[c] {
+			return fmt.Errorf("short code alphabet contains duplicate character %q", c)
+		}
+		seen[c] = true
+	}
+	return nil
+}
+
+// randomGenerator — криптостойкие случайные коды. Если конфликты идут подряд,
+// пространство кодов текущей длины заполняется, и длина растёт для всех следующих вызовов.
+type randomGenerator struct {
+	alphabet  string
+	growAfter int
+	length    atomic.Int32
+}
+
+func (g *randomGenerator) Generate(_ context.Context, attempt int) (string, error) {
+	n := int(g.length.Load())
+	if attempt > 0 && attempt%g.growAfter == 0 && n < MaxLength {
+		g.length.CompareAndSwap(int32(n), int32(n+1))
+		n = int(g.length.Load())
+	}
+	return randomString(g.alphabet, n)
+}
+
+func randomString(alphabet string, n int) (string, error) {
+	// отбрасываем байты из неполного хвоста, чтобы распределение было равномерным
+	limit := 256 - 256%len(alphabet)
+	out := make([]byte, 0, n)
+	buf := make([]byte, n*2)
+	for len(out) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+			out = append(out, alphabet[int(b)%len(alphabet)])
+			if len(out) == n {
+				break
+			}
+		}
+	}
+	return string(out), nil
+}
+
+// sequenceGenerator кодирует номер из sequence в системе счисления алфавита.
+// В режиме obfuscated номер сначала переставляется внутри пространства кодов
+// текущей длины, поэтому соседние ссылки получают непохожие коды.
+type sequenceGenerator struct {
+	seq        SequenceSource
+	alphabet   string
+	length     int
+	obfuscated bool
+	salt       uint64
+}
+
+func (g *sequenceGenerator) Generate(ctx context.Context, _ int) (string, error) {
+	n, err := g.seq.NextShortSequence(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get next sequence value: %w", err)
+	}
+	if n < 0 {
+		return "", fmt.Errorf("negative sequence value %d", n)
+	}
+
+	value := big.NewInt(n)
+	base := big.NewInt(int64(len(g.alphabet)))
+
+	// наименьшая длина не короче минимальной, в которую помещается номер
+	length := g.length
+	space := new(big.Int).Exp(base, big.NewInt(int64(length)), nil)
+	for value.Cmp(space) >= 0 {
+		length++
+		space.Mul(space, base)
+	}
+	if length > MaxLength {
+		return "", fmt.Errorf("short code keyspace exhausted")
+	}
+
+	if g.obfuscated {
+		value = permute(value, space, base, g.salt)
+	}
+
+	return encode(value, g.alphabet, length), nil
+}
+
+// obfuscationMultiplier — большое простое; перестановка x -> (x*m + salt) mod space
+// биективна, если m взаимно просто с основанием алфавита
+const obfuscationMultiplier = 1_580_030_173
+
+func permute(value, space, base *big.Int, salt uint64) *big.Int {
+	m := big.NewInt(obfuscationMultiplier)
+	one := big.NewInt(1)
+	for new(big.Int).GCD(nil, nil, m, base).Cmp(one) != 0 {
+		m.Add(m, big.NewInt(2))
+	}
+
+	out := new(big.Int).Mul(value, m)
+	out.Add(out, new(big.Int).SetUint64(salt))
+	return out.Mod(out, space)
+}
+
+func encode(value *big.Int, alphabet string, length int) string {
+	base := big.NewInt(int64(len(alphabet)))
+	v := new(big.Int).Set(value)
+	mod := new(big.Int)
+
+	out := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		v.DivMod(v, base, mod)
+		out[i] = alphabet[mod.Int64()]
+	}
+	return string(out)
+}
@@ -0,0 +1,182 @@
+package shortcode
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// counter — SequenceSource, выдающий 0, 1, 2, ...
+type counter struct{ next int64 }
+
+func (c *counter) NextShortSequence(context.Context) (int64, error) {
+	n := c.next
+	c.next++
+	return n, nil
+}
+
+func TestNewRejectsBadConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		seq  SequenceSource
+	}{
+		{"short alphabet", Config{Length: 6, Alphabet: "a"}, nil},
+		{"duplicate character", Config{Length: 6, Alphabet: "abca"}, nil},
+		{"unsafe character", Config{Length: 6, Alphabet: "ab/"}, nil},
+		{"zero length", Config{Length: 0}, nil},
+		{"too long", Config{Length: MaxLength + 1}, nil},
+		{"unknown strategy", Config{Strategy: "uuid", Length: 6}, nil},
+		{"sequence without source", Config{Strategy: StrategySequence, Length: 6}, nil},
+		{"obfuscated without source", Config{Strategy: StrategyObfuscated, Length: 6}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg, tt.seq); err == nil {
+				t.Fatalf("New(%+v) succeeded, want error", tt.cfg)
+			}
+		})
+	}
+}
+
+func TestSequenceNeverRepeats(t *testing.T) {
+	for _, strategy := range []string{StrategySequence, StrategyObfuscated} {
+		t.Run(strategy, func(t *testing.T) {
+			// алфавит из двух символов: длина 3 вмещает 8 кодов, 4 — ещё 8 и т.д.
+			gen, err := New(Config{Strategy: strategy, Length: 3, Alphabet: "ab", Salt: 5}, &counter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			seen := map[string]bool{}
+			for n := 0; n < 64; n++ {
+				code, err := gen.Generate(context.Background(), 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if seen[code] {
+					t.Fatalf("code %q for sequence value %d repeats", code, n)
+				}
+				seen[code] = true
+
+				want := 3
+				for space := 8; n >= space; space *= 2 {
+					want++
+				}
+				if len(code) != want {
+					t.Fatalf("sequence value %d: got %q (length %d), want length %d", n, code, len(code), want)
+				}
+			}
+		})
+	}
+}
+
+func TestSequenceKeyspaceExhausted(t *testing.T) {
+	gen, err := New(Config{Strategy: StrategySequence, Length: MaxLength, Alphabet: "ab"}, &counter{next: 1 << 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gen.Generate(context.Background(), 0); err == nil {
+		t.Fatal("expected keyspace error for a value that does not fit into MaxLength")
+	}
+}
+
+func TestPermuteIsBijective(t *testing.T) {
+	tests := []struct {
+		base   int64
+		length int64
+		salt   uint64
+	}{
+		{2, 10, 0},
+		{10, 3, 7},
+		{62, 2, 12345},
+	}
+	for _, tt := range tests {
+		base := big.NewInt(tt.base)
+		space := new(big.Int).Exp(base, big.NewInt(tt.length), nil)
+
+		seen := map[string]bool{}
+		for x := int64(0); x < space.Int64(); x++ {
+			out := permute(big.NewInt(x), space, base, tt.salt)
+			if out.Sign() < 0 || out.Cmp(space) >= 0 {
+				t.Fatalf("base %d: permute(%d) = %s is outside the keyspace", tt.base, x, out)
+			}
+			if seen[out.String()] {
+				t.Fatalf("base %d length %d: permute(%d) = %s repeats", tt.base, tt.length, x, out)
+			}
+			seen[out.String()] = true
+		}
+	}
+}
+
+func TestRandomUniqueAndInAlphabet(t *testing.T) {
+	gen, err := New(Config{Length: 10}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < 10000; i++ {
+		code, err := gen.Generate(context.Background(), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != 10 {
+			t.Fatalf("got %q, want length 10", code)
+		}
+		for _, c := range code {
+			if !strings.ContainsRune(DefaultAlphabet, c) {
+				t.Fatalf("code %q contains %q outside the alphabet", code, c)
+			}
+		}
+		if seen[code] {
+			t.Fatalf("code %q repeats", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestRandomGrowsOnCollisions(t *testing.T) {
+	gen, err := New(Config{Length: 4, GrowAfter: 2}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		attempt int
+		want    int
+	}{
+		{0, 4},
+		{1, 4},
+		{2, 5}, // второй конфликт подряд — длина растёт
+		{0, 5}, // и остаётся большей для следующих ссылок
+		{3, 5},
+		{4, 6},
+	}
+	for _, tt := range tests {
+		code, err := gen.Generate(context.Background(), tt.attempt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != tt.want {
+			t.Fatalf("attempt %d: got %q (length %d), want length %d", tt.attempt, code, len(code), tt.want)
+		}
+	}
+}
+
+func TestRandomStopsGrowingAtMaxLength(t *testing.T) {
+	gen, err := New(Config{Length: MaxLength, GrowAfter: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for attempt := 0; attempt < 5; attempt++ {
+		code, err := gen.Generate(context.Background(), attempt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != MaxLength {
+			t.Fatalf("attempt %d: got length %d, want %d", attempt, len(code), MaxLength)
+		}
+	}
+}
@@ -17,6 +17,7 @@ const (
 	ErrFieldBelowMinLen   = "Field is below minimum length"
 	ErrFieldExceedsMaxVal = "Field exceeds maximum value"
 	ErrFieldBelowMinVal   = "Field is below minimum value"
+	ErrValueNotAllowed    = "Value is not allowed"
 	ErrUnknownValidation  = "Unknown validation error"
 )
 
@@ -73,6 +74,8 @@ func parseValidationErrors(err error) error {
 		validationErrorDescription = ErrFieldExceedsMaxVal
 	case "gt", "gte":
 		validationErrorDescription = ErrFieldBelowMinVal
+	case "oneof":
+		validationErrorDescription = ErrValueNotAllowed
 	default:
 		validationErrorDescription = ErrUnknownValidation
 	}
@@ -0,0 +1,36 @@
+-- ===========================
+-- Тестовые данные
+-- ===========================
+
+-- Фикстуру можно загружать повторно: ссылка, код которой уже занят (в том числе настоящей
+-- ссылкой), пропускается вместе со своими кликами, существующие данные не трогаются.
+-- Добавляем 3 тестовые ссылки в workspace по умолчанию и клики только к добавленным
+WITH inserted AS (
+    INSERT INTO urls (short, original, custom_alias, created_at, workspace_id)
+    SELECT v.short, v.original, v.custom_alias, v.created_at::timestamp, (SELECT MIN(id) FROM workspaces)
+    FROM (VALUES
+              ('abc123', 'https://example.com/page1', NULL, '2025-07-15 10:00:00'),
+              ('def456', 'https://example.com/page2', 'myalias', '2025-08-08 12:00:00'),
+              ('ghi789', 'https://example.com/page3', NULL, '2025-08-23 09:00:00')
+         ) AS v(short, original, custom_alias, created_at)
+    ON CONFLICT DO NOTHING
+    RETURNING short
+)
+INSERT INTO clicks (short, created_at, ip, referer, browser, os, device, raw_ua)
+SELECT c.short, c.created_at::timestamp, c.ip, c.referer, c.browser, c.os, c.device, c.raw_ua
+FROM (VALUES
+          -- abc123: старше 30 дней
+          ('abc123', '2025-07-10 14:00:00', '192.168.1.1', 'https://google.com', 'Chrome', 'Windows', 'Desktop', 'UA1'),
+          ('abc123', '2025-07-12 09:00:00', '192.168.1.2', 'https://bing.com', 'Firefox', 'Linux', 'Desktop', 'UA2'),
+          ('abc123', '2025-07-14 18:30:00', '192.168.1.3', NULL, 'Safari', 'macOS', 'Desktop', 'UA3'),
+          -- def456: Last30Days, но не Last7Days
+          ('def456', '2025-08-05 11:00:00', '10.0.0.1', 'https://example.com', 'Chrome', 'Windows', 'Desktop', 'UA4'),
+          ('def456', '2025-08-10 16:45:00', '10.0.0.2', NULL, 'Safari', 'iOS', 'Mobile', 'UA5'),
+          ('def456', '2025-08-15 08:20:00', '10.0.0.3', 'https://google.com', 'Firefox', 'Android', 'Mobile', 'UA6'),
+          -- ghi789: Last7Days и Last30Days
+          ('ghi789', '2025-08-22 10:00:00', '172.16.0.1', 'https://bing.com', 'Chrome', 'Windows', 'Desktop', 'UA7'),
+          ('ghi789', '2025-08-25 12:30:00', '172.16.0.2', 'https://yahoo.com', 'Edge', 'Windows', 'Desktop', 'UA8'),
+          ('ghi789', '2025-08-26 15:15:00', '172.16.0.3', 'https://duckduckgo.com', 'Safari', 'macOS', 'Desktop', 'UA9'),
+          ('ghi789', '2025-08-27 09:45:00', '172.16.0.4', NULL, 'Chrome', 'Android', 'Mobile', 'UA10')
+     ) AS c(short, created_at, ip, referer, browser, os, device, raw_ua)
+WHERE c.short IN (SELECT short FROM inserted);